@@ -0,0 +1,13 @@
+// The errorsvet command reports misuses of the github.com/bynil/errors
+// package, see the errorsvet analyzer. It can be run on its own or by go
+// vet:
+//
+//	go vet -vettool=$(which errorsvet) ./...
+package main
+
+import (
+	"github.com/bynil/errors/analysis/errorsvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() { singlechecker.Main(errorsvet.Analyzer) }
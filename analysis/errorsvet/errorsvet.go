@@ -0,0 +1,573 @@
+// Package errorsvet defines an Analyzer which reports misuses of the
+// github.com/bynil/errors package:
+//
+//   - errors returned from calls into other packages without a stack trace,
+//     such as return err after os.Open, which should be wrapped by
+//     errors.WithStack or errors.Wrap. Only the errors which certainly come
+//     from such a call are reported, and not those returned by the Read and
+//     Write methods implementing the io interfaces, which must return
+//     sentinel errors such as io.EOF unchanged;
+//   - errors given a second stack trace, such as errors.Wrap(errors.Wrap(...))
+//     or errors.WithStack on an error created by errors.New;
+//   - the %w verb given to errors.Errorf and the other formatting functions,
+//     which do not support it;
+//   - the unused results of errors.WithMessage and the other functions
+//     annotating an error.
+//
+// Most reports come with a suggested fix. The Analyzer can be run by go vet
+// with the errorsvet command:
+//
+//	go install github.com/bynil/errors/analysis/cmd/errorsvet@latest
+//	go vet -vettool=$(which errorsvet) ./...
+package errorsvet
+
+import (
+	"bytes"
+	"go/ast"
+	"go/constant"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// PkgPath is the import path of the checked package.
+const PkgPath = "github.com/bynil/errors"
+
+// Analyzer reports misuses of the github.com/bynil/errors package.
+var Analyzer = &analysis.Analyzer{
+	Name:     "errorsvet",
+	Doc:      "report misuses of github.com/bynil/errors: unannotated errors from other packages, double stack traces, %w in Errorf and unused annotations",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// stackers add a stack trace to an error they are given.
+var stackers = map[string]bool{
+	"WithStack": true,
+	"Wrap":      true,
+	"Wrapf":     true,
+	"WrapCtx":   true,
+	"WrapfCtx":  true,
+	"WrapType":  true,
+	"WrapTypef": true,
+}
+
+// recorders create an error with a stack trace.
+var recorders = map[string]bool{
+	"New":                       true,
+	"NewCtx":                    true,
+	"Errorf":                    true,
+	"ErrorfCtx":                 true,
+	"NewI18n":                   true,
+	"FromContext":               true,
+	"LimitExceededAfter":        true,
+	"LimitExceededUntil":        true,
+	"LimitExceededRate":         true,
+	"LimitExceededRatef":        true,
+	"UnauthenticatedChallenge":  true,
+	"UnauthenticatedChallengef": true,
+}
+
+// annotators return a copy of the error they are given, their result must
+// be used.
+var annotators = map[string]bool{
+	"WithMessage":  true,
+	"WithMessagef": true,
+}
+
+// noStack gives the function annotating an error like a stacker, without a
+// stack trace.
+var noStack = map[string]string{
+	"Wrap":  "WithMessage",
+	"Wrapf": "WithMessagef",
+}
+
+func init() {
+	for name := range stackers {
+		recorders[name] = true
+		annotators[name] = true
+	}
+	for _, name := range []string{
+		"Internal", "Validation", "Input", "Duplicate", "Unauthenticated",
+		"NoPermission", "Empty", "NotFound", "LimitExceeded",
+		"SubscriptionExpired", "Unavailable", "Timeout", "Canceled",
+	} {
+		recorders[name] = true
+		recorders[name+"f"] = true
+		recorders[name+"Ctx"] = true
+		recorders[name+"fCtx"] = true
+	}
+}
+
+// assignment records that an error variable was assigned the result of a
+// call to fn, nil if unknown, by the statement ending at end. The statement
+// is executed each time block is, the innermost statement containing it
+// which may be skipped or repeated.
+type assignment struct {
+	end   token.Pos
+	fn    *types.Func
+	block ast.Node
+}
+
+type checker struct {
+	pass    *analysis.Pass
+	assigns map[types.Object][]assignment
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	c := &checker{
+		pass:    pass,
+		assigns: make(map[types.Object][]assignment),
+	}
+
+	// Assignments are recorded first, in source order, so that the last
+	// one before a use can be looked up.
+	inspect.WithStack([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		block := innermostBlock(stack[:len(stack)-1])
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			c.assign(n.Lhs, n.Rhs, n.End(), block)
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(n.Names))
+			for i, name := range n.Names {
+				lhs[i] = name
+			}
+			c.assign(lhs, n.Values, n.End(), block)
+		}
+		return true
+	})
+
+	inspect.WithStack([]ast.Node{(*ast.ReturnStmt)(nil), (*ast.CallExpr)(nil), (*ast.ExprStmt)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.ReturnStmt:
+			c.checkReturn(n, stack)
+		case *ast.CallExpr:
+			if fn := c.callee(n); isPkgFunc(fn) {
+				c.checkDoubleStack(n, fn, stack)
+				c.checkFormat(n, fn)
+			}
+		case *ast.ExprStmt:
+			c.checkUnused(n)
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// innermostBlock returns the innermost of the enclosing nodes stack which
+// may be skipped or repeated: a block, clause, branch or loop statement, or
+// a function.
+func innermostBlock(stack []ast.Node) ast.Node {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch stack[i].(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause, *ast.IfStmt,
+			*ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt,
+			*ast.SelectStmt, *ast.FuncDecl, *ast.FuncLit:
+			return stack[i]
+		}
+	}
+	return nil
+}
+
+// assign records the assignments of calls to error variables.
+func (c *checker) assign(lhs, rhs []ast.Expr, end token.Pos, block ast.Node) {
+	var fn *types.Func
+	if len(rhs) == 1 {
+		if call, ok := astutil.Unparen(rhs[0]).(*ast.CallExpr); ok {
+			fn = c.callee(call)
+		}
+	}
+	for i, e := range lhs {
+		id, ok := e.(*ast.Ident)
+		if !ok || id.Name == "_" {
+			continue
+		}
+		obj := c.pass.TypesInfo.ObjectOf(id)
+		if obj == nil || !isError(obj.Type()) {
+			continue
+		}
+		a := assignment{end: end, block: block}
+		if len(rhs) == 1 {
+			a.fn = fn
+		} else if i < len(rhs) {
+			if call, ok := astutil.Unparen(rhs[i]).(*ast.CallExpr); ok {
+				a.fn = c.callee(call)
+			}
+		}
+		c.assigns[obj] = append(c.assigns[obj], a)
+	}
+}
+
+// lastCall returns the function whose result is the value of the error
+// variable id used at pos, within the enclosing nodes stack, or nil if it is
+// unknown. The value is known if it was assigned by a statement executed
+// whenever the use is, and not by any statement which may be executed
+// between them, such as one in a branch, or after the use in a loop.
+func (c *checker) lastCall(id *ast.Ident, pos token.Pos, stack []ast.Node) *types.Func {
+	encloses := func(n ast.Node) bool {
+		for _, s := range stack {
+			if s == n {
+				return true
+			}
+		}
+		return false
+	}
+	var last *assignment
+	ambiguous := false
+	for _, a := range c.assigns[c.pass.TypesInfo.Uses[id]] {
+		a := a
+		switch {
+		case a.end <= pos && encloses(a.block):
+			last, ambiguous = &a, false
+		case a.end <= pos:
+			ambiguous = true
+		case inLoop(a.end, pos, stack):
+			return nil
+		}
+	}
+	if last == nil || ambiguous {
+		return nil
+	}
+	return last.fn
+}
+
+// inLoop reports whether pos and end, after it, are in the same loop of the
+// enclosing nodes stack of pos, so that the statement ending at end may be
+// executed before pos.
+func inLoop(end, pos token.Pos, stack []ast.Node) bool {
+	for _, n := range stack {
+		switch n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			if end < n.End() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkReturn reports the error variables returned as they were returned by
+// a call into another package, outside the methods implementing io.Reader
+// or io.Writer.
+func (c *checker) checkReturn(ret *ast.ReturnStmt, stack []ast.Node) {
+	if fn := enclosingFunc(stack); fn != nil && c.isReadWrite(fn) {
+		return
+	}
+	for _, res := range ret.Results {
+		id, ok := astutil.Unparen(res).(*ast.Ident)
+		if !ok || !isError(c.pass.TypesInfo.TypeOf(id)) {
+			continue
+		}
+		fn := c.lastCall(id, ret.Pos(), stack)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg() == c.pass.Pkg || fn.Pkg().Path() == PkgPath {
+			continue
+		}
+		d := analysis.Diagnostic{
+			Pos:     id.Pos(),
+			End:     id.End(),
+			Message: "error returned by " + fn.FullName() + " is returned without a stack trace, wrap it with errors.WithStack or errors.Wrap",
+		}
+		// The callers of a function returning the error of a Read or Write
+		// method may compare it with io.EOF, which a fix would break.
+		if name, ok := c.importName(id.Pos()); ok && !isReadWrite(fn) {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message: "Wrap with " + name + ".WithStack",
+				TextEdits: []analysis.TextEdit{{
+					Pos:     id.Pos(),
+					End:     id.End(),
+					NewText: []byte(name + ".WithStack(" + id.Name + ")"),
+				}},
+			}}
+		}
+		c.pass.Report(d)
+	}
+}
+
+// checkDoubleStack reports the stackers given an error which already has a
+// stack trace.
+func (c *checker) checkDoubleStack(call *ast.CallExpr, fn *types.Func, stack []ast.Node) {
+	if !stackers[fn.Name()] {
+		return
+	}
+	arg := c.errorArg(call, fn)
+	if arg == nil {
+		return
+	}
+	var inner *types.Func
+	switch arg := astutil.Unparen(arg).(type) {
+	case *ast.CallExpr:
+		inner = c.callee(arg)
+	case *ast.Ident:
+		inner = c.lastCall(arg, call.Pos(), stack)
+	}
+	if !isPkgFunc(inner) || !recorders[inner.Name()] {
+		return
+	}
+
+	d := analysis.Diagnostic{
+		Pos:     call.Pos(),
+		End:     call.End(),
+		Message: "errors." + fn.Name() + " records a second stack trace, the error returned by errors." + inner.Name() + " already has one",
+	}
+	if fn.Name() == "WithStack" {
+		d.SuggestedFixes = []analysis.SuggestedFix{{
+			Message: "Remove errors.WithStack",
+			TextEdits: []analysis.TextEdit{{
+				Pos:     call.Pos(),
+				End:     call.End(),
+				NewText: c.source(arg),
+			}},
+		}}
+	} else if name, ok := noStack[fn.Name()]; ok {
+		d.Message += ", use errors." + name
+		if id := funcIdent(call); id != nil {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message: "Replace with errors." + name,
+				TextEdits: []analysis.TextEdit{{
+					Pos:     id.Pos(),
+					End:     id.End(),
+					NewText: []byte(name),
+				}},
+			}}
+		}
+	}
+	c.pass.Report(d)
+}
+
+// checkFormat reports the %w verbs in the constant formats given to the
+// formatting functions.
+func (c *checker) checkFormat(call *ast.CallExpr, fn *types.Func) {
+	sig := fn.Type().(*types.Signature)
+	params := sig.Params()
+	if !sig.Variadic() || params.Len() < 2 || params.At(params.Len()-2).Name() != "format" {
+		return
+	}
+	i := params.Len() - 2
+	if i >= len(call.Args) {
+		return
+	}
+	arg := call.Args[i]
+	tv := c.pass.TypesInfo.Types[arg]
+	if tv.Value == nil || tv.Value.Kind() != constant.String || !hasWrapVerb(constant.StringVal(tv.Value)) {
+		return
+	}
+
+	d := analysis.Diagnostic{
+		Pos:     arg.Pos(),
+		End:     arg.End(),
+		Message: "errors." + fn.Name() + " does not support the %w verb, the error is formatted as a string and is not a cause",
+	}
+	if fn.Name() == "Errorf" {
+		d.Message += ", use errors.Wrapf"
+	}
+	if lit, ok := astutil.Unparen(arg).(*ast.BasicLit); ok {
+		d.SuggestedFixes = []analysis.SuggestedFix{{
+			Message: "Replace %w with %v",
+			TextEdits: []analysis.TextEdit{{
+				Pos:     lit.Pos(),
+				End:     lit.End(),
+				NewText: []byte(replaceWrapVerb(lit.Value)),
+			}},
+		}}
+	}
+	c.pass.Report(d)
+}
+
+// checkUnused reports the annotators whose result is discarded.
+func (c *checker) checkUnused(stmt *ast.ExprStmt) {
+	call, ok := astutil.Unparen(stmt.X).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	fn := c.callee(call)
+	if !isPkgFunc(fn) || !annotators[fn.Name()] {
+		return
+	}
+
+	d := analysis.Diagnostic{
+		Pos:     call.Pos(),
+		End:     call.End(),
+		Message: "result of errors." + fn.Name() + " call not used, the error is not changed in place",
+	}
+	if arg := c.errorArg(call, fn); arg != nil {
+		if id, ok := astutil.Unparen(arg).(*ast.Ident); ok && id.Name != "nil" {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message: "Assign the result to " + id.Name,
+				TextEdits: []analysis.TextEdit{{
+					Pos:     call.Pos(),
+					End:     call.Pos(),
+					NewText: []byte(id.Name + " = "),
+				}},
+			}}
+		}
+	}
+	c.pass.Report(d)
+}
+
+// callee returns the function or method called by call, or nil if it is
+// not statically known.
+func (c *checker) callee(call *ast.CallExpr) *types.Func {
+	fn, _ := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	return fn
+}
+
+// errorArg returns the first argument of call given to an error parameter
+// of fn.
+func (c *checker) errorArg(call *ast.CallExpr, fn *types.Func) ast.Expr {
+	params := fn.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len() && i < len(call.Args); i++ {
+		if isError(params.At(i).Type()) {
+			return call.Args[i]
+		}
+	}
+	return nil
+}
+
+// importName returns the name under which the file containing pos imports
+// PkgPath.
+func (c *checker) importName(pos token.Pos) (string, bool) {
+	for _, f := range c.pass.Files {
+		if pos < f.Pos() || pos > f.End() {
+			continue
+		}
+		for _, imp := range f.Imports {
+			if path, _ := strconv.Unquote(imp.Path.Value); path != PkgPath {
+				continue
+			}
+			if imp.Name != nil {
+				return imp.Name.Name, imp.Name.Name != "_" && imp.Name.Name != "."
+			}
+			return "errors", true
+		}
+	}
+	return "", false
+}
+
+// source returns the source text of e.
+func (c *checker) source(e ast.Expr) []byte {
+	var buf bytes.Buffer
+	format.Node(&buf, c.pass.Fset, e)
+	return buf.Bytes()
+}
+
+// funcIdent returns the identifier naming the function called by call.
+func funcIdent(call *ast.CallExpr) *ast.Ident {
+	switch fun := astutil.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	}
+	return nil
+}
+
+// enclosingFunc returns the innermost function declaration of the
+// enclosing nodes stack, or nil if it is in a function literal or none.
+func enclosingFunc(stack []ast.Node) *ast.FuncDecl {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.FuncDecl:
+			return n
+		case *ast.FuncLit:
+			return nil
+		}
+	}
+	return nil
+}
+
+// isReadWrite reports whether the method declared by decl implements
+// io.Reader or io.Writer.
+func (c *checker) isReadWrite(decl *ast.FuncDecl) bool {
+	fn, _ := c.pass.TypesInfo.Defs[decl.Name].(*types.Func)
+	return fn != nil && isReadWrite(fn)
+}
+
+// isReadWrite reports whether fn is a Read or Write method with the
+// signature of io.Reader or io.Writer, whose errors, such as io.EOF, are
+// compared by their callers and must be returned unchanged.
+func isReadWrite(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() == nil || (fn.Name() != "Read" && fn.Name() != "Write") {
+		return false
+	}
+	params, results := sig.Params(), sig.Results()
+	if params.Len() != 1 || results.Len() != 2 {
+		return false
+	}
+	p, ok := params.At(0).Type().(*types.Slice)
+	return ok && types.Identical(p.Elem(), types.Typ[types.Byte]) &&
+		types.Identical(results.At(0).Type(), types.Typ[types.Int]) && isError(results.At(1).Type())
+}
+
+// isPkgFunc reports whether fn is a function of PkgPath, or a method of its
+// Factory.
+func isPkgFunc(fn *types.Func) bool {
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == PkgPath
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func isError(t types.Type) bool {
+	return t != nil && types.Identical(t, errorType)
+}
+
+// hasWrapVerb reports whether format contains a %w verb.
+func hasWrapVerb(format string) bool {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		j := verbEnd(format, i+1)
+		if j < len(format) && format[j] == 'w' {
+			return true
+		}
+		i = j
+	}
+	return false
+}
+
+// replaceWrapVerb replaces the %w verbs of the quoted format with %v.
+func replaceWrapVerb(quoted string) string {
+	var b strings.Builder
+	for i := 0; i < len(quoted); i++ {
+		b.WriteByte(quoted[i])
+		if quoted[i] != '%' {
+			continue
+		}
+		j := verbEnd(quoted, i+1)
+		b.WriteString(quoted[i+1 : j])
+		if j < len(quoted) {
+			if quoted[j] == 'w' {
+				b.WriteByte('v')
+			} else {
+				b.WriteByte(quoted[j])
+			}
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// verbEnd returns the index of the verb of the directive starting at i,
+// after its flags, width, precision and argument index.
+func verbEnd(format string, i int) int {
+	for i < len(format) && strings.IndexByte("+-# 0123456789.*[]", format[i]) >= 0 {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,12 @@
+package errorsvet_test
+
+import (
+	"testing"
+
+	"github.com/bynil/errors/analysis/errorsvet"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), errorsvet.Analyzer, "a")
+}
@@ -0,0 +1,105 @@
+package a
+
+import (
+	"io"
+	"os"
+
+	"github.com/bynil/errors"
+)
+
+func open(name string) (*os.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err // want `error returned by os.Open is returned without a stack trace`
+	}
+	return f, nil
+}
+
+func openWrapped(name string) (*os.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "open")
+	}
+	return f, nil
+}
+
+func openRewrapped(name string) error {
+	_, err := os.Open(name)
+	err = errors.WithStack(err)
+	return err
+}
+
+func openLocal(name string) error {
+	_, err := open(name)
+	return err
+}
+
+func read(r io.Reader, buf []byte) error {
+	var err error
+	_, err = r.Read(buf)
+	return err // want `error returned by \(io.Reader\).Read is returned without a stack trace`
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func openMaybe(name string, create bool) error {
+	err := check(name)
+	if create {
+		_, err = os.Create(name)
+	}
+	return err
+}
+
+func openRetry(names []string) error {
+	var err error
+	for _, name := range names {
+		if err != nil {
+			return err
+		}
+		_, err = os.Open(name)
+	}
+	return nil
+}
+
+func check(name string) error {
+	if name == "" {
+		return errors.New("no name")
+	}
+	return nil
+}
+
+func doubleStack(f *errors.Factory) {
+	_ = errors.Wrap(errors.Wrap(io.EOF, "a"), "b")    // want `errors.Wrap records a second stack trace, the error returned by errors.Wrap already has one, use errors.WithMessage`
+	_ = errors.Wrapf(errors.NotFound("a"), "b %d", 1) // want `errors.Wrapf records a second stack trace, the error returned by errors.NotFound already has one, use errors.WithMessagef`
+	_ = errors.WithStack(errors.New("a"))             // want `errors.WithStack records a second stack trace, the error returned by errors.New already has one`
+	_ = f.Wrap(errors.NotFoundf("a %d", 1), "b")      // want `errors.Wrap records a second stack trace`
+
+	err := errors.New("a")
+	_ = errors.WithStack(err) // want `errors.WithStack records a second stack trace`
+
+	_ = errors.Wrap(errors.WithMessage(io.EOF, "a"), "b")
+	_ = errors.Wrap(io.EOF, "a")
+}
+
+func wrapVerb(err error) {
+	_ = errors.Errorf("read: %w", err)        // want `errors.Errorf does not support the %w verb, the error is formatted as a string and is not a cause, use errors.Wrapf`
+	_ = errors.NotFoundf("%d%%w %+w", 1, err) // want `errors.NotFoundf does not support the %w verb`
+	_ = errors.WithMessagef(err, "read: %v", err)
+	_ = errors.Errorf("100%%w")
+}
+
+func unused(err error) error {
+	errors.WithMessage(err, "a") // want `result of errors.WithMessage call not used`
+	errors.Wrap(io.EOF, "a")     // want `result of errors.Wrap call not used`
+	errors.New("a")
+	return err
+}
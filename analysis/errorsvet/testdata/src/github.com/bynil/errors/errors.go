@@ -0,0 +1,35 @@
+// Package errors stubs the functions of github.com/bynil/errors used by the
+// tests of errorsvet.
+package errors
+
+import "fmt"
+
+type fundamental struct{ msg string }
+
+func (f *fundamental) Error() string { return f.msg }
+
+func New(message string) error { return &fundamental{message} }
+
+func Errorf(format string, args ...interface{}) error {
+	return &fundamental{fmt.Sprintf(format, args...)}
+}
+
+func NotFound(message string) error { return New(message) }
+
+func NotFoundf(format string, args ...interface{}) error { return Errorf(format, args...) }
+
+func WithStack(err error) error { return err }
+
+func Wrap(err error, message string) error { return err }
+
+func Wrapf(err error, format string, args ...interface{}) error { return err }
+
+func WithMessage(err error, message string) error { return err }
+
+func WithMessagef(err error, format string, args ...interface{}) error { return err }
+
+type Factory struct{}
+
+func (f *Factory) Wrap(err error, message string) error { return err }
+
+func (f *Factory) WithMessage(err error, message string) error { return err }
@@ -1,8 +1,10 @@
 package errors
 
 import (
+	"fmt"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"net/http"
+	"strconv"
 )
 
 // APIError returns an HTTP status code and an API-safe error message.
@@ -21,6 +23,9 @@ type Typer interface {
 type CustomType struct {
 	Detail     string
 	StatusCode int
+	// Internal marks the type as internal: in Production mode, errors of
+	// the type are rendered like those of TypeInternal.
+	Internal bool
 }
 
 func NewCustomType(detail string, statusCode int) CustomType {
@@ -34,6 +39,11 @@ func (c CustomType) HTTPStatusCode() int {
 	return c.StatusCode
 }
 
+// IsInternal reports whether the type is marked internal.
+func (c CustomType) IsInternal() bool {
+	return c.Internal
+}
+
 type errType int
 
 // While adding a new Type, the respective helper functions should be added, also update the
@@ -59,18 +69,61 @@ const (
 	TypeLimitExceeded
 	// TypeSubscriptionExpired is error type for when a user's 'paid' account has expired
 	TypeSubscriptionExpired
+	// TypeUnavailable is error type for when a dependency is temporarily unavailable e.g. connection refused
+	TypeUnavailable
+	// TypeTimeout is error type for when an operation or a dependency timed out
+	TypeTimeout
+	// TypeCanceled is error type for when an operation was canceled e.g. the client closed the request
+	TypeCanceled
 
 	// DefaultMessage is the default user friendly message
 	DefaultMessage = "unknown error occurred"
 )
 
-var (
-	defaultErrType = TypeInternal
-)
+// StatusClientClosedRequest is the non-standard HTTP status code of
+// TypeCanceled, as used by nginx for requests closed by the client.
+const StatusClientClosedRequest = 499
 
-// SetDefaultType will set the default error type, which is used in the 'New' function
+// SetDefaultType will set the default error type, which is used in the 'New' function.
+// It sets the DefaultType of the default Factory, and only affects errors created afterwards.
 func SetDefaultType(e errType) {
-	defaultErrType = e
+	updateDefault(func(cfg *Config) { cfg.DefaultType = e })
+}
+
+var typeNames = [...]string{
+	TypeInternal:            "internal",
+	TypeValidation:          "validation",
+	TypeInput:               "input",
+	TypeDuplicate:           "duplicate",
+	TypeUnauthenticated:     "unauthenticated",
+	TypeNoPermission:        "no_permission",
+	TypeEmpty:               "empty",
+	TypeNotFound:            "not_found",
+	TypeLimitExceeded:       "limit_exceeded",
+	TypeSubscriptionExpired: "subscription_expired",
+	TypeUnavailable:         "unavailable",
+	TypeTimeout:             "timeout",
+	TypeCanceled:            "canceled",
+}
+
+// String returns the snake case name of the error type, e.g. not_found.
+func (e errType) String() string {
+	if e < 0 || int(e) >= len(typeNames) {
+		return "errType(" + strconv.Itoa(int(e)) + ")"
+	}
+	return typeNames[e]
+}
+
+// typeName returns the name of et used in error responses.
+func typeName(et Typer) string {
+	switch et := et.(type) {
+	case CustomType:
+		return et.Detail
+	case fmt.Stringer:
+		return et.String()
+	default:
+		return fmt.Sprint(et)
+	}
 }
 
 // HTTPStatusCode is a convenience method used to get the appropriate HTTP response status code for the respective error type
@@ -118,6 +171,18 @@ func (e errType) HTTPStatusCode() int {
 		{
 			status = http.StatusPaymentRequired
 		}
+	case TypeUnavailable:
+		{
+			status = http.StatusServiceUnavailable
+		}
+	case TypeTimeout:
+		{
+			status = http.StatusGatewayTimeout
+		}
+	case TypeCanceled:
+		{
+			status = StatusClientClosedRequest
+		}
 	}
 
 	return status
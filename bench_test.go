@@ -4,6 +4,9 @@ package errors
 
 import (
 	"fmt"
+	"io"
+	"runtime"
+	"strconv"
 	"testing"
 
 	stderrors "errors"
@@ -108,3 +111,56 @@ func BenchmarkStackFormatting(b *testing.B) {
 	}
 	GlobalE = stackStr
 }
+
+// uncachedFrame formats like Frame did before symbols were cached: every
+// accessor performs its own runtime.FuncForPC lookup.
+type uncachedFrame Frame
+
+func (f uncachedFrame) Format(s fmt.State, verb rune) {
+	pc := Frame(f).pc()
+	fn := runtime.FuncForPC(pc)
+	io.WriteString(s, fn.Name())
+	io.WriteString(s, "\n\t")
+	fn = runtime.FuncForPC(pc)
+	file, _ := fn.FileLine(pc)
+	io.WriteString(s, file)
+	io.WriteString(s, ":")
+	fn = runtime.FuncForPC(pc)
+	_, line := fn.FileLine(pc)
+	io.WriteString(s, strconv.Itoa(line))
+}
+
+func BenchmarkFrameSymbolization(b *testing.B) {
+	st := yesErrors(0, 30).(*fundamental).StackTrace()
+
+	var frameStr string
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, f := range st {
+				frameStr = fmt.Sprintf("%+v", uncachedFrame(f))
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, f := range st {
+				frameStr = fmt.Sprintf("%+v", f)
+			}
+		}
+	})
+	GlobalE = frameStr
+}
+
+func BenchmarkStackTrace(b *testing.B) {
+	err := yesErrors(0, 30).(*fundamental)
+
+	var st StackTrace
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		st = err.StackTrace()
+	}
+	b.StopTimer()
+	GlobalE = st
+}
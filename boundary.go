@@ -0,0 +1,97 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Opaque returns an error marking a boundary between err and the layer
+// returning it. The error has the type eType: the types and status codes of
+// the errors inside the boundary no longer propagate to Wrap, GetAPIError or
+// HasType with StopAtBoundary, while err remains the cause for logging,
+// Cause and Unwrap. Neither do their response headers, rate limits,
+// challenges, severities, incident IDs and localized messages. Opaque also
+// records the stack trace at the point it was called. If err is nil, Opaque
+// returns nil.
+func Opaque(err error, eType Typer) error {
+	if err == nil {
+		return nil
+	}
+	err = &boundary{
+		err,
+		eType,
+		callers(),
+	}
+	Default().created(context.Background(), err)
+	return err
+}
+
+// Boundary is like Opaque, using the default type set by SetDefaultType.
+func Boundary(err error) error {
+	if err == nil {
+		return nil
+	}
+	err = &boundary{
+		err,
+		Default().cfg.DefaultType,
+		callers(),
+	}
+	Default().created(context.Background(), err)
+	return err
+}
+
+type boundary struct {
+	error
+	eType Typer
+	*stack
+}
+
+func (b *boundary) Cause() error { return b.error }
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (b *boundary) Unwrap() error { return b.error }
+
+func (b *boundary) messageTemplate() (string, []interface{}) { return templateOf(b.error) }
+
+func (b *boundary) isBoundary() {}
+
+func (b *boundary) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", b.Cause())
+			b.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, b.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", b.Error())
+	}
+}
+
+func (b *boundary) Type() Typer { return b.eType }
+
+func (b *boundary) APIError() (int, string) {
+	return b.Type().HTTPStatusCode(), b.Error()
+}
+
+// isBoundary reports whether err marks a boundary created by Opaque or Boundary.
+func isBoundary(err error) bool {
+	_, ok := err.(interface {
+		isBoundary()
+	})
+	return ok
+}
+
+// unwrapOutside is like Unwrap, but returns nil if err marks a boundary. It
+// is used to look up the metadata which, like the type, decides how an error
+// is reported to clients, so that the errors inside a boundary are ignored.
+func unwrapOutside(err error) error {
+	if isBoundary(err) {
+		return nil
+	}
+	return Unwrap(err)
+}
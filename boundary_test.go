@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+func TestOpaque(t *testing.T) {
+	if Opaque(nil, TypeUnavailable) != nil || Boundary(nil) != nil {
+		t.Errorf("Opaque(nil): got non-nil error")
+	}
+
+	upstream := NotFound("user not found")
+	err := Wrap(Opaque(upstream, TypeUnavailable), "load profile")
+
+	if code, msg := GetAPIError(err); code != http.StatusServiceUnavailable || msg != "load profile: user not found" {
+		t.Errorf("GetAPIError: got %d, %q", code, msg)
+	}
+	if got := getErrType(err); got != TypeUnavailable {
+		t.Errorf("getErrType: got %v, want %v", got, TypeUnavailable)
+	}
+	if Cause(err) != upstream || !Is(err, upstream) {
+		t.Errorf("Opaque: cause chain not kept")
+	}
+
+	tests := []struct {
+		et   Typer
+		opts []TypeOption
+		want bool
+	}{
+		{TypeNotFound, nil, true},
+		{TypeNotFound, []TypeOption{StopAtBoundary}, false},
+		{TypeUnavailable, []TypeOption{StopAtBoundary}, true},
+		{TypeUnavailable, nil, true},
+	}
+	for _, tt := range tests {
+		if got := HasType(err, tt.et, tt.opts...); got != tt.want {
+			t.Errorf("HasType(%v, %v): got %v, want %v", tt.et, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestBoundary(t *testing.T) {
+	err := Boundary(LimitExceeded("upstream rate limited"))
+	if code, _ := GetAPIError(err); code != http.StatusInternalServerError {
+		t.Errorf("GetAPIError: got %d, want %d", code, http.StatusInternalServerError)
+	}
+	if HasType(err, TypeLimitExceeded, StopAtBoundary) || !HasType(err, TypeLimitExceeded) {
+		t.Errorf("HasType: boundary not respected")
+	}
+
+	want := "^EOF\n" +
+		"github.com/bynil/errors.TestBoundary\n" +
+		"\t.+/boundary_test.go:\\d+"
+	if got := fmt.Sprintf("%+v", Boundary(io.EOF)); !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("%%+v: got %q, want match for %q", got, want)
+	}
+}
+
+func TestBoundaryHidesMetadata(t *testing.T) {
+	limited := WithHeader(LimitExceededRate(RateLimit{RetryAfter: time.Minute, Limit: 100}, "upstream rate limited"), "X-Upstream", "a")
+	unauthenticated := WithIncidentID(UnauthenticatedChallenge(Challenge{Scheme: "Bearer"}, "upstream token expired"), "inc-upstream")
+	lc := &i18n.LocalizeConfig{MessageID: "upstream_message"}
+
+	tests := []error{
+		Wrap(Boundary(limited), "call upstream"),
+		Opaque(limited, TypeUnavailable),
+		Wrap(Boundary(WithSeverity(unauthenticated, SeverityCritical)), "call upstream"),
+		Opaque(unauthenticated, TypeUnavailable),
+		Boundary(NewI18n(TypeNotFound, lc)),
+	}
+	for _, err := range tests {
+		rec := httptest.NewRecorder()
+		WriteHTTP(rec, err)
+		for _, name := range []string{"Retry-After", "Ratelimit-Limit", "X-Upstream", "Www-Authenticate"} {
+			if v := rec.Header().Get(name); v != "" {
+				t.Errorf("%v: header %s set from inside the boundary: %q", err, name, v)
+			}
+		}
+		if v := rec.Header().Get("X-Incident-Id"); v == "inc-upstream" {
+			t.Errorf("%v: incident ID taken from inside the boundary", err)
+		}
+		if _, ok := RetryAfter(err); ok {
+			t.Errorf("%v: RetryAfter found inside the boundary", err)
+		}
+		if _, ok := GetRateLimit(err); ok {
+			t.Errorf("%v: GetRateLimit found inside the boundary", err)
+		}
+		if _, ok := GetChallenge(err); ok {
+			t.Errorf("%v: GetChallenge found inside the boundary", err)
+		}
+		if IsRetryable(err) != HasType(err, TypeUnavailable, StopAtBoundary) {
+			t.Errorf("%v: IsRetryable classified the errors inside the boundary", err)
+		}
+		if id := IncidentID(err); id != "" {
+			t.Errorf("%v: IncidentID found inside the boundary: %q", err, id)
+		}
+		if s := GetSeverity(err); s != SeverityError {
+			t.Errorf("%v: GetSeverity: got %v, want %v", err, s, SeverityError)
+		}
+		if lc := localizeConfigOf(err); lc != nil {
+			t.Errorf("%v: localize config found inside the boundary", err)
+		}
+	}
+
+	if got := ownMessage(Opaque(limited, TypeUnavailable)); got != "" {
+		t.Errorf("ownMessage: got %q, want the message inside the boundary hidden", got)
+	}
+	if got := ownMessage(Wrap(Boundary(limited), "call upstream")); got != "call upstream" {
+		t.Errorf("ownMessage: got %q", got)
+	}
+}
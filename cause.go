@@ -0,0 +1,87 @@
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Cancel cancels a context through cancel, as returned by
+// context.WithCancelCause, with a new error of type eType and the supplied
+// message, and returns that error. The error records the stack trace at the
+// point Cancel was called, and is reported by context.Cause and FromContext.
+func Cancel(cancel context.CancelCauseFunc, eType Typer, message string) error {
+	err := Default().newErr(context.Background(), message, template{}, eType)
+	cancel(err)
+	return err
+}
+
+// FromContext returns nil if ctx is not done. Otherwise it returns the cause
+// of ctx, as reported by context.Cause, annotated with a stack trace at the
+// point FromContext was called and with the fields extracted from ctx.
+//
+// If the cause has no type, e.g. because ctx was canceled without a cause,
+// the error is of type TypeTimeout if the deadline of ctx was exceeded, and
+// of type TypeCanceled otherwise. Errors set as cause by Cancel keep their
+// type.
+func FromContext(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	cause := context.Cause(ctx)
+	if !hasTyper(cause) {
+		eType := TypeCanceled
+		if Is(cause, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded {
+			eType = TypeTimeout
+		}
+		cause = &withType{cause, eType}
+	}
+	var err error = &withStack{
+		cause,
+		callers(),
+		captureOrigin(),
+	}
+	err = Default().withContext(ctx, err)
+	Default().created(ctx, err)
+	return err
+}
+
+// withType gives an error without a type, such as context.Canceled, a type.
+type withType struct {
+	error
+	eType Typer
+}
+
+func (w *withType) Cause() error { return w.error }
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withType) Unwrap() error { return w.error }
+
+func (w *withType) messageTemplate() (string, []interface{}) { return templateOf(w.error) }
+
+func (w *withType) Type() Typer { return w.eType }
+
+func (w *withType) APIError() (int, string) {
+	return w.Type().HTTPStatusCode(), w.Error()
+}
+
+// MarshalJSON implements json.Marshaler.
+func (w *withType) MarshalJSON() ([]byte, error) { return marshalJSON(w) }
+
+func (w *withType) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", w.Cause())
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
@@ -0,0 +1,99 @@
+//go:build go1.20
+// +build go1.20
+
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	want := Cancel(cancel, TypeLimitExceeded, "quota exhausted")
+	if got := context.Cause(ctx); got != want {
+		t.Errorf("context.Cause: got %v, want %v", got, want)
+	}
+	if ctx.Err() != context.Canceled {
+		t.Errorf("ctx.Err: got %v, want %v", ctx.Err(), context.Canceled)
+	}
+	if len(stackOf(want)) == 0 {
+		t.Errorf("Cancel: no stack trace recorded")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if err := FromContext(context.Background()); err != nil {
+		t.Errorf("FromContext(Background): got %v, want nil", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	deadline, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	caused, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(io.EOF)
+	typed, cancelCause := context.WithCancelCause(context.Background())
+	Cancel(cancelCause, TypeUnavailable, "shutting down")
+
+	tests := []struct {
+		ctx      context.Context
+		wantType Typer
+		wantMsg  string
+		wantIs   error
+	}{
+		{canceled, TypeCanceled, "context canceled", context.Canceled},
+		{deadline, TypeTimeout, "context deadline exceeded", context.DeadlineExceeded},
+		{caused, TypeCanceled, "EOF", io.EOF},
+		{typed, TypeUnavailable, "shutting down", nil},
+	}
+	for i, tt := range tests {
+		err := FromContext(tt.ctx)
+		if err == nil {
+			t.Fatalf("test %d: FromContext: got nil", i+1)
+		}
+		if got := getErrType(err); got != tt.wantType {
+			t.Errorf("test %d: type: got %v, want %v", i+1, got, tt.wantType)
+		}
+		if code, msg := GetAPIError(err); code != tt.wantType.HTTPStatusCode() || msg != tt.wantMsg {
+			t.Errorf("test %d: GetAPIError: got %d %q, want %d %q", i+1, code, msg, tt.wantType.HTTPStatusCode(), tt.wantMsg)
+		}
+		if tt.wantIs != nil && !Is(err, tt.wantIs) {
+			t.Errorf("test %d: Is(%v): got false", i+1, tt.wantIs)
+		}
+	}
+}
+
+func TestFromContextFormat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := FromContext(ctx)
+	want := "context canceled\n" +
+		"github.com/bynil/errors.TestFromContextFormat\n" +
+		"\t.+/github.com/bynil/errors/cause_test.go:75"
+	if got := fmt.Sprintf("%+v", err); !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("%%+v:\n got %q\n want %q", got, want)
+	}
+}
+
+func TestWithTypeTemplate(t *testing.T) {
+	err := &withType{Errorf("user %d not found", 42), TypeCanceled}
+	if got := Template(err); got != "user %d not found" {
+		t.Errorf("Template: got %q", got)
+	}
+}
+
+func TestWithTypeMarshalJSON(t *testing.T) {
+	b, err := json.Marshal(&withType{New("inner"), TypeCanceled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^\{"message":"inner","stack":\[`).Match(b) {
+		t.Errorf("json.Marshal: got %s", b)
+	}
+}
@@ -0,0 +1,115 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error codes for Bearer token challenges, see RFC 6750 section 3.1.
+const (
+	ChallengeInvalidRequest    = "invalid_request"
+	ChallengeInvalidToken      = "invalid_token"
+	ChallengeInsufficientScope = "insufficient_scope"
+)
+
+// Challenge is an authentication challenge sent in the WWW-Authenticate
+// header of 401 responses, see RFC 9110 section 11.6.1, and of 403 responses
+// to Bearer requests lacking a scope, see RFC 6750 section 3.1.
+type Challenge struct {
+	// Scheme is the authentication scheme, e.g. Bearer or Basic.
+	Scheme string
+	Realm  string
+	// Scope is the space separated list of scopes required by the resource.
+	Scope string
+	// Error is one of the Challenge* error codes for Bearer challenges.
+	Error            string
+	ErrorDescription string
+}
+
+// String returns c formatted as a WWW-Authenticate header value.
+func (c Challenge) String() string {
+	var params []string
+	for _, p := range []struct{ name, value string }{
+		{"realm", c.Realm},
+		{"scope", c.Scope},
+		{"error", c.Error},
+		{"error_description", c.ErrorDescription},
+	} {
+		if p.value != "" {
+			params = append(params, p.name+"="+quote(p.value))
+		}
+	}
+	if len(params) == 0 {
+		return c.Scheme
+	}
+	return c.Scheme + " " + strings.Join(params, ", ")
+}
+
+// quote returns s as an HTTP quoted-string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// UnauthenticatedChallenge is a helper function to create a new error of type
+// TypeUnauthenticated carrying the challenge c.
+func UnauthenticatedChallenge(c Challenge, message string) error {
+	return Default().newAnnotated(context.Background(), message, template{}, TypeUnauthenticated, withChallenge(c))
+}
+
+// UnauthenticatedChallengef is a helper function to create a new error of type
+// TypeUnauthenticated carrying the challenge c, with formatted message.
+func UnauthenticatedChallengef(c Challenge, format string, args ...interface{}) error {
+	return Default().newAnnotated(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeUnauthenticated, withChallenge(c))
+}
+
+// WithChallenge annotates err with the challenge c, replacing any challenge
+// carried by err's chain. If err is nil, WithChallenge returns nil.
+func WithChallenge(err error, c Challenge) error {
+	if err == nil {
+		return nil
+	}
+	err = withChallenge(c)(err)
+	Default().created(context.Background(), err)
+	return err
+}
+
+// withChallenge returns a function annotating an error with c.
+func withChallenge(c Challenge) func(error) error {
+	return func(err error) error {
+		return withMetadata(err, challenge{c})
+	}
+}
+
+// challenge is the metadata added by withChallenge.
+type challenge struct {
+	c Challenge
+}
+
+func (c challenge) Challenge() Challenge { return c.c }
+
+// GetChallenge returns the challenge of the outermost error in err's chain
+// which carries one, ignoring the errors inside a boundary.
+func GetChallenge(err error) (Challenge, bool) {
+	for err != nil {
+		if c, ok := metaOf(err).(interface {
+			Challenge() Challenge
+		}); ok {
+			return c.Challenge(), true
+		}
+		err = unwrapOutside(err)
+	}
+	return Challenge{}, false
+}
+
+// setChallengeHeader sets the WWW-Authenticate header of 401 and 403
+// responses from the challenge carried by err, if any.
+func setChallengeHeader(h http.Header, code int, err error) {
+	if code != http.StatusUnauthorized && code != http.StatusForbidden {
+		return
+	}
+	if c, ok := GetChallenge(err); ok && c.Scheme != "" {
+		h.Set("WWW-Authenticate", c.String())
+	}
+}
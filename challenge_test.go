@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChallengeString(t *testing.T) {
+	tests := []struct {
+		c    Challenge
+		want string
+	}{
+		{Challenge{Scheme: "Basic"}, "Basic"},
+		{Challenge{Scheme: "Basic", Realm: "staging"}, `Basic realm="staging"`},
+		{
+			Challenge{
+				Scheme:           "Bearer",
+				Realm:            "example",
+				Error:            ChallengeInvalidToken,
+				ErrorDescription: `token "abc" expired`,
+			},
+			`Bearer realm="example", error="invalid_token", error_description="token \"abc\" expired"`,
+		},
+		{
+			Challenge{Scheme: "Bearer", Scope: "read write", Error: ChallengeInsufficientScope},
+			`Bearer scope="read write", error="insufficient_scope"`,
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("Challenge.String(): got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestGetChallenge(t *testing.T) {
+	bearer := Challenge{Scheme: "Bearer", Realm: "api"}
+	err := Wrap(UnauthenticatedChallenge(bearer, "token required"), "authenticate")
+	if !HasType(err, TypeUnauthenticated) {
+		t.Errorf("UnauthenticatedChallenge: %v is not of type TypeUnauthenticated", err)
+	}
+	if got, ok := GetChallenge(err); !ok || got != bearer {
+		t.Errorf("GetChallenge: got %+v, %v, want %+v", got, ok, bearer)
+	}
+
+	basic := Challenge{Scheme: "Basic", Realm: "api"}
+	if got, _ := GetChallenge(WithChallenge(err, basic)); got != basic {
+		t.Errorf("GetChallenge: got %+v, want overridden %+v", got, basic)
+	}
+	if _, ok := GetChallenge(Unauthenticated("no")); ok {
+		t.Errorf("GetChallenge: found a challenge on a plain error")
+	}
+	if WithChallenge(nil, basic) != nil {
+		t.Errorf("WithChallenge(nil): got non-nil error")
+	}
+}
+
+func TestWriteHTTPChallenge(t *testing.T) {
+	c := Challenge{Scheme: "Bearer", Realm: "api", Error: ChallengeInvalidToken}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"challenge", UnauthenticatedChallengef(c, "token %s expired", "abc"), `Bearer realm="api", error="invalid_token"`},
+		{"no challenge", Unauthenticated("token required"), ""},
+		{"insufficient scope", WithChallenge(NoPermission("forbidden"), Challenge{Scheme: "Bearer", Error: ChallengeInsufficientScope, Scope: "orders:write"}), `Bearer scope="orders:write", error="insufficient_scope"`},
+		{"not authentication", WithChallenge(NotFound("missing"), c), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteHTTP(rec, tt.err)
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.want {
+				t.Errorf("WWW-Authenticate: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	WriteHTTP(rec, UnauthenticatedChallenge(c, "token required"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status: got %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,274 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ClientError describes a failed outbound HTTP request, either a transport
+// error or a response with a 4xx or 5xx status code.
+type ClientError struct {
+	// Method is the method of the request.
+	Method string
+	// URL is the URL of the request, with its password and query values redacted.
+	URL string
+	// StatusCode is the status code of the response, zero for transport errors.
+	StatusCode int
+	// Body is the beginning of the response body, at most MaxBodySnippet
+	// bytes, see Config.
+	Body string
+	// RequestID is the request ID found in the RequestIDHeaders of the
+	// response, see Config.
+	RequestID string
+	// Err is the transport error, nil if a response was received. A *url.Error
+	// returned by http.Client is replaced by the error it wraps, so that the
+	// unredacted URL does not end up in messages.
+	Err error
+
+	eType Typer
+	*stack
+}
+
+// FromHTTP returns an error describing the outcome of the request req, which
+// received the response resp or failed with err, as returned by
+// http.Client.Do. It returns nil if err is nil and resp is nil or has a
+// status code below 400, e.g. a redirect.
+//
+// The error is classified by the response status, e.g. TypeNotFound for 404
+// and TypeLimitExceeded for 429, or, for transport errors, as TypeTimeout
+// or TypeUnavailable. The beginning of the response body is recorded, and
+// resp.Body is replaced so that it can still be read in full by the caller.
+//
+// A *url.Error, which http.Client returns with the unredacted URL in its
+// message, is replaced by the error it wraps. If that is a ClientError, as
+// for the errors of the transport returned by RoundTripper, it is returned
+// as is.
+func FromHTTP(req *http.Request, resp *http.Response, err error) error {
+	return Default().fromHTTP(req, resp, err)
+}
+
+// FromHTTP is like the FromHTTP function, using the MaxBodySnippet and
+// RequestIDHeaders of f.
+func (f *Factory) FromHTTP(req *http.Request, resp *http.Response, err error) error {
+	return f.fromHTTP(req, resp, err)
+}
+
+func (f *Factory) fromHTTP(req *http.Request, resp *http.Response, err error) error {
+	if err == nil && (resp == nil || resp.StatusCode < 400) {
+		return nil
+	}
+	if ue, ok := err.(*url.Error); ok {
+		if ce, ok := ue.Err.(*ClientError); ok {
+			return ce
+		}
+		err = ue.Err
+	}
+	if req == nil && resp != nil {
+		req = resp.Request
+	}
+	ce := &ClientError{
+		Err:   err,
+		stack: f.callers(),
+	}
+	ctx := context.Background()
+	if req != nil {
+		ce.Method = req.Method
+		ce.URL = redactURL(req.URL)
+		ctx = req.Context()
+	}
+	if err != nil {
+		ce.eType = transportType(err)
+		f.created(ctx, ce)
+		return ce
+	}
+	ce.StatusCode = resp.StatusCode
+	ce.eType = statusType(resp.StatusCode)
+	for _, name := range f.cfg.RequestIDHeaders {
+		if id := resp.Header.Get(name); id != "" {
+			ce.RequestID = id
+			break
+		}
+	}
+	if resp.Body != nil {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, int64(f.cfg.MaxBodySnippet)))
+		ce.Body = string(snippet)
+		resp.Body = &readCloser{io.MultiReader(bytes.NewReader(snippet), resp.Body), resp.Body}
+	}
+	f.created(ctx, ce)
+	return ce
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// redactURL returns u with its password and the values of its query
+// parameters redacted.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	if _, ok := redacted.User.Password(); ok {
+		redacted.User = url.UserPassword(redacted.User.Username(), "xxxxx")
+	}
+	if q := redacted.Query(); len(q) > 0 {
+		for k := range q {
+			q[k] = []string{"REDACTED"}
+		}
+		redacted.RawQuery = q.Encode()
+	}
+	return redacted.String()
+}
+
+// statusType returns the error type of an upstream response status code.
+func statusType(code int) Typer {
+	switch code {
+	case http.StatusBadRequest:
+		return TypeInput
+	case http.StatusUnauthorized:
+		return TypeUnauthenticated
+	case http.StatusPaymentRequired:
+		return TypeSubscriptionExpired
+	case http.StatusForbidden:
+		return TypeNoPermission
+	case http.StatusNotFound:
+		return TypeNotFound
+	case http.StatusConflict:
+		return TypeDuplicate
+	case http.StatusGone:
+		return TypeEmpty
+	case http.StatusUnprocessableEntity:
+		return TypeValidation
+	case http.StatusTooManyRequests:
+		return TypeLimitExceeded
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return TypeUnavailable
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return TypeTimeout
+	}
+	return TypeInternal
+}
+
+// transportType returns the error type of a transport error.
+func transportType(err error) Typer {
+	if t, ok := err.(interface {
+		Timeout() bool
+	}); ok && t.Timeout() {
+		return TypeTimeout
+	}
+	if Is(err, context.DeadlineExceeded) {
+		return TypeTimeout
+	}
+	return TypeUnavailable
+}
+
+func (c *ClientError) Error() string {
+	prefix := c.Method + " " + c.URL + ": "
+	if c.Err != nil {
+		return prefix + c.Err.Error()
+	}
+	return prefix + strconv.Itoa(c.StatusCode) + " " + http.StatusText(c.StatusCode)
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (c *ClientError) Unwrap() error { return c.Err }
+
+// messageTemplate keeps the method, URL and status out of the template, so
+// that the failures of requests to different URLs are grouped.
+func (c *ClientError) messageTemplate() (string, []interface{}) {
+	args := []interface{}{c.Method, c.URL}
+	if c.Err != nil {
+		format, errArgs := templateOf(c.Err)
+		return "%s %s: " + format, append(args, errArgs...)
+	}
+	return "%s %s: %d %s", append(args, c.StatusCode, http.StatusText(c.StatusCode))
+}
+
+func (c *ClientError) Type() Typer { return c.eType }
+
+func (c *ClientError) APIError() (int, string) {
+	return c.Type().HTTPStatusCode(), c.Error()
+}
+
+func (c *ClientError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, c.Error())
+			if c.RequestID != "" {
+				io.WriteString(s, "\nrequest id: "+c.RequestID)
+			}
+			if c.Body != "" {
+				fmt.Fprintf(s, "\nresponse body: %q", c.Body)
+			}
+			c.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, c.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", c.Error())
+	}
+}
+
+// RoundTripper returns an http.RoundTripper which sends requests with base,
+// or http.DefaultTransport if base is nil, and turns transport errors and
+// 4xx and 5xx responses into errors built by FromHTTP. The body of such a
+// response is closed, as the response is not returned. Other responses, such
+// as redirects and 304 Not Modified, are returned unchanged.
+//
+// Note that this deviates from the http.RoundTripper contract, which asks
+// implementations to return any response they obtain, so it should only be
+// used by clients which treat every 4xx and 5xx response as a failure.
+//
+// http.Client wraps the errors of its transport in a *url.Error, whose
+// message contains the unredacted URL of the request. Pass the error
+// returned by the client to FromHTTP, or use As, to get the ClientError
+// before logging or returning it. Also note that http.Client replaces the
+// error of requests exceeding Client.Timeout; use a context deadline to get
+// a TypeTimeout error instead.
+func RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{base: base}
+}
+
+// RoundTripper is like the RoundTripper function, building the errors with
+// the FromHTTP method of f.
+func (f *Factory) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{base, f}
+}
+
+// roundTripper builds its errors with f, or with the default Factory at the
+// time of the request if f is nil.
+type roundTripper struct {
+	base http.RoundTripper
+	f    *Factory
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.base.RoundTrip(req)
+	f := rt.f
+	if f == nil {
+		f = Default()
+	}
+	if err := f.fromHTTP(req, resp, err); err != nil {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,526 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+// Classifier returns the type of err, an error without a type such as one
+// of another package. The boolean is false if it cannot classify err.
+type Classifier func(err error) (Typer, bool)
+
+// StackOptions configures the stack traces recorded by a Factory.
+type StackOptions struct {
+	// Depth is the maximum number of frames recorded, 32 if zero.
+	Depth int
+	// Origin enables the capture of the creation time and goroutine, see
+	// SetOriginCapture.
+	Origin bool
+}
+
+// Config configures a Factory.
+type Config struct {
+	// DefaultType is the type of the errors created without one, such as by
+	// New, and of wrapped errors which have none. TypeInternal if nil.
+	DefaultType Typer
+	// Bundle localizes the errors created by NewI18n, and their messages in
+	// error responses. An empty English bundle if nil.
+	Bundle *i18n.Bundle
+	// Language is the language of the messages of the errors created by
+	// NewI18n, en if empty.
+	Language string
+	// Stack configures the recorded stack traces.
+	Stack StackOptions
+	// Hooks are called with every error created by the Factory, after the
+	// hooks registered with OnCreate.
+	Hooks []Hook
+	// Retry is the policy whose fields are used for the zero fields of the
+	// policy passed to the Retry method. Its own zero fields are 3 attempts, a backoff
+	// of 100ms growing twofold up to 10s, and a jitter of 0.2.
+	Retry RetryPolicy
+	// MaxBodySnippet is the maximum number of bytes of a response body
+	// recorded by the FromHTTP method, 1024 if zero.
+	MaxBodySnippet int
+	// RequestIDHeaders are the response headers searched, in order, for the
+	// request ID assigned by an upstream service, see ClientError, and the
+	// request headers searched for the correlation ID rendered by HTTPWriter
+	// in Production mode. If nil, X-Request-Id, X-Correlation-Id,
+	// X-Amzn-Requestid and Request-Id.
+	RequestIDHeaders []string
+	// IncidentHeader is the response header carrying the incident ID of
+	// the responses written by the WriteHTTP method and by HTTPWriter,
+	// X-Incident-Id if empty.
+	IncidentHeader string
+	// HandlerLog is the LogFunc used by every Handler without its own Log.
+	// Nothing is logged if nil.
+	HandlerLog LogFunc
+	// Fingerprint are the options used by the Fingerprint method.
+	Fingerprint FingerprintOptions
+	// Extractors extract the fields attached to the errors created by the
+	// Factory with a context, such as by NewCtx, see RegisterExtractor.
+	Extractors []*FieldExtractor
+	// Mode is the Mode of the responses written by the WriteHTTP method, and
+	// by every HTTPWriter without a mode of its own. Standard if ModeUnset.
+	Mode Mode
+	// Classifiers give a type to the errors without one wrapped by the
+	// Factory and, for the default Factory, to those passed to GetAPIError
+	// and thus rendered by WriteHTTP and HTTPWriter. The first classifier
+	// returning true wins, the DefaultType is used if none does.
+	Classifiers []Classifier
+}
+
+// Factory creates errors according to its Config. The package level
+// functions, such as New and Wrap, use the default Factory, see Default.
+// A Factory is immutable and safe for concurrent use.
+type Factory struct {
+	cfg       Config
+	localizer *i18n.Localizer
+	hooks     []*hook
+}
+
+// NewFactory returns a Factory with the configuration cfg.
+func NewFactory(cfg Config) *Factory {
+	if cfg.DefaultType == nil {
+		cfg.DefaultType = TypeInternal
+	}
+	if cfg.Bundle == nil {
+		cfg.Bundle = i18n.NewBundle(language.English)
+	}
+	if cfg.Language == "" {
+		cfg.Language = "en"
+	}
+	if cfg.Stack.Depth <= 0 {
+		cfg.Stack.Depth = 32
+	}
+	cfg.Retry = cfg.Retry.withDefaults(defaultRetryPolicy)
+	if cfg.MaxBodySnippet <= 0 {
+		cfg.MaxBodySnippet = 1024
+	}
+	if cfg.RequestIDHeaders == nil {
+		cfg.RequestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "X-Amzn-Requestid", "Request-Id"}
+	}
+	if cfg.IncidentHeader == "" {
+		cfg.IncidentHeader = "X-Incident-Id"
+	}
+	if cfg.Mode == ModeUnset {
+		cfg.Mode = Standard
+	}
+	cfg.RequestIDHeaders = append([]string(nil), cfg.RequestIDHeaders...)
+	cfg.Extractors = append([]*FieldExtractor(nil), cfg.Extractors...)
+	cfg.Hooks = append([]Hook(nil), cfg.Hooks...)
+	cfg.Classifiers = append([]Classifier(nil), cfg.Classifiers...)
+	f := &Factory{
+		cfg:       cfg,
+		localizer: i18n.NewLocalizer(cfg.Bundle, cfg.Language),
+	}
+	for _, fn := range cfg.Hooks {
+		f.hooks = append(f.hooks, &hook{fn: fn, rate: 1})
+	}
+	return f
+}
+
+// Config returns the configuration of f, with the defaults filled in.
+func (f *Factory) Config() Config {
+	cfg := f.cfg
+	cfg.RequestIDHeaders = append([]string(nil), cfg.RequestIDHeaders...)
+	cfg.Extractors = append([]*FieldExtractor(nil), cfg.Extractors...)
+	cfg.Hooks = append([]Hook(nil), cfg.Hooks...)
+	cfg.Classifiers = append([]Classifier(nil), cfg.Classifiers...)
+	return cfg
+}
+
+var (
+	stdMu sync.Mutex
+	std   atomic.Value // *Factory
+)
+
+func init() {
+	std.Store(NewFactory(Config{}))
+}
+
+// Default returns the default Factory, used by the package level functions.
+func Default() *Factory {
+	return std.Load().(*Factory)
+}
+
+// SetDefault replaces the default Factory. It is safe to call concurrently
+// with the creation of errors, which use either the old or the new Factory.
+func SetDefault(f *Factory) {
+	stdMu.Lock()
+	defer stdMu.Unlock()
+	std.Store(f)
+}
+
+// updateDefault replaces the default Factory with one whose configuration
+// is changed by fn.
+func updateDefault(fn func(cfg *Config)) {
+	stdMu.Lock()
+	defer stdMu.Unlock()
+	cfg := Default().Config()
+	fn(&cfg)
+	std.Store(NewFactory(cfg))
+}
+
+// callers records the stack trace of the caller of the exported function
+// calling the unexported constructor calling callers.
+func (f *Factory) callers() *stack {
+	return captureStack(5, f.cfg.Stack.Depth)
+}
+
+func captureStack(skip, depth int) *stack {
+	pcs := make([]uintptr, depth)
+	n := runtime.Callers(skip, pcs)
+	return &stack{pcs: pcs[0:n]}
+}
+
+// captureOrigin returns the current origin if capture is enabled, otherwise nil.
+func (f *Factory) captureOrigin() *origin {
+	if !f.cfg.Stack.Origin {
+		return nil
+	}
+	return newOrigin()
+}
+
+// created invokes the hooks registered with OnCreate and those of f.
+func (f *Factory) created(ctx context.Context, err error) {
+	runHooks(ctx, err, f.hooks)
+}
+
+// typeOf returns the type of the first error in err's chain, outside any
+// boundary, which has one. If none has, it returns the type given by the
+// classifiers, or the default type.
+func (f *Factory) typeOf(err error) Typer {
+	for e := err; e != nil; e = unwrapOutside(e) {
+		if t, ok := e.(interface {
+			Type() Typer
+		}); ok {
+			return t.Type()
+		}
+	}
+	if err != nil {
+		for _, classify := range f.cfg.Classifiers {
+			if t, ok := classify(err); ok {
+				return t
+			}
+		}
+	}
+	return f.cfg.DefaultType
+}
+
+// newErr creates an error of type eType, or of the default type if eType is
+// nil, with the given message and attaches the fields extracted from ctx.
+func (f *Factory) newErr(ctx context.Context, message string, tmpl template, eType Typer) error {
+	err := f.withContext(ctx, f.fundamental(message, tmpl, eType, f.callers()))
+	f.created(ctx, err)
+	return err
+}
+
+// newAnnotated is like newErr, and annotates the error with annotate before
+// invoking the hooks.
+func (f *Factory) newAnnotated(ctx context.Context, message string, tmpl template, eType Typer, annotate func(error) error) error {
+	err := annotate(f.withContext(ctx, f.fundamental(message, tmpl, eType, f.callers())))
+	f.created(ctx, err)
+	return err
+}
+
+func (f *Factory) fundamental(message string, tmpl template, eType Typer, st *stack) error {
+	if eType == nil {
+		eType = f.cfg.DefaultType
+	}
+	return &fundamental{
+		msg:    message,
+		tmpl:   tmpl,
+		eType:  eType,
+		stack:  st,
+		origin: f.captureOrigin(),
+	}
+}
+
+// newI18n creates an error of type eType, or of the default type if eType
+// is nil, whose message is localized by lc.
+func (f *Factory) newI18n(eType Typer, lc *i18n.LocalizeConfig) error {
+	if eType == nil {
+		eType = f.cfg.DefaultType
+	}
+	err := &localization{
+		lc:     lc,
+		eType:  eType,
+		bundle: f.cfg.Bundle,
+		stack:  f.callers(),
+		origin: f.captureOrigin(),
+	}
+	err.msg, _ = f.localizer.Localize(lc)
+	f.created(context.Background(), err)
+	return err
+}
+
+func (f *Factory) withStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	err = &withStack{
+		err,
+		f.callers(),
+		f.captureOrigin(),
+	}
+	f.created(context.Background(), err)
+	return err
+}
+
+// wrap annotates err with a message and a stack trace, and attaches the
+// fields extracted from ctx. The type of the result is eType, or that of
+// err if eType is nil.
+func (f *Factory) wrap(ctx context.Context, err error, message string, tmpl template, eType Typer) error {
+	if err == nil {
+		return nil
+	}
+	if eType == nil {
+		eType = f.typeOf(err)
+	}
+	err = &withMessage{
+		cause: err,
+		msg:   message,
+		tmpl:  tmpl,
+		eType: eType,
+	}
+	err = &withStack{
+		err,
+		f.callers(),
+		f.captureOrigin(),
+	}
+	err = f.withContext(ctx, err)
+	f.created(ctx, err)
+	return err
+}
+
+func (f *Factory) withMessage(err error, message string, tmpl template) error {
+	if err == nil {
+		return nil
+	}
+	err = &withMessage{
+		cause: err,
+		msg:   message,
+		tmpl:  tmpl,
+		eType: f.typeOf(err),
+	}
+	f.created(context.Background(), err)
+	return err
+}
+
+// New is like the package level New, using the configuration of f.
+func (f *Factory) New(message string) error {
+	return f.newErr(context.Background(), message, template{}, nil)
+}
+
+// NewCtx is like the package level NewCtx, using the configuration of f.
+func (f *Factory) NewCtx(ctx context.Context, message string) error {
+	return f.newErr(ctx, message, template{}, nil)
+}
+
+// Errorf is like the package level Errorf, using the configuration of f.
+func (f *Factory) Errorf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, nil)
+}
+
+// ErrorfCtx is like the package level ErrorfCtx, using the configuration of f.
+func (f *Factory) ErrorfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return f.newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, nil)
+}
+
+// NewI18n is like the package level NewI18n, using the configuration of f.
+func (f *Factory) NewI18n(eType Typer, lc *i18n.LocalizeConfig) error {
+	return f.newI18n(eType, lc)
+}
+
+// WithStack is like the package level WithStack, using the configuration of f.
+func (f *Factory) WithStack(err error) error {
+	return f.withStack(err)
+}
+
+// Wrap is like the package level Wrap, using the configuration of f.
+func (f *Factory) Wrap(err error, message string) error {
+	return f.wrap(context.Background(), err, message, template{}, nil)
+}
+
+// WrapCtx is like the package level WrapCtx, using the configuration of f.
+func (f *Factory) WrapCtx(ctx context.Context, err error, message string) error {
+	return f.wrap(ctx, err, message, template{}, nil)
+}
+
+// Wrapf is like the package level Wrapf, using the configuration of f.
+func (f *Factory) Wrapf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return f.wrap(context.Background(), err, fmt.Sprintf(format, args...), template{format, args}, nil)
+}
+
+// WrapfCtx is like the package level WrapfCtx, using the configuration of f.
+func (f *Factory) WrapfCtx(ctx context.Context, err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return f.wrap(ctx, err, fmt.Sprintf(format, args...), template{format, args}, nil)
+}
+
+// WrapType is like the package level WrapType, using the configuration of f.
+func (f *Factory) WrapType(err error, eType Typer, message string) error {
+	return f.wrap(context.Background(), err, message, template{}, eType)
+}
+
+// WrapTypef is like the package level WrapTypef, using the configuration of f.
+func (f *Factory) WrapTypef(err error, eType Typer, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return f.wrap(context.Background(), err, fmt.Sprintf(format, args...), template{format, args}, eType)
+}
+
+// WithMessage is like the package level WithMessage, using the configuration of f.
+func (f *Factory) WithMessage(err error, message string) error {
+	return f.withMessage(err, message, template{})
+}
+
+// WithMessagef is like the package level WithMessagef, using the configuration of f.
+func (f *Factory) WithMessagef(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return f.withMessage(err, fmt.Sprintf(format, args...), template{format, args})
+}
+
+// Internal is like the package level Internal, using the configuration of f.
+func (f *Factory) Internal(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeInternal)
+}
+
+// Internalf is like the package level Internalf, using the configuration of f.
+func (f *Factory) Internalf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeInternal)
+}
+
+// Validation is like the package level Validation, using the configuration of f.
+func (f *Factory) Validation(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeValidation)
+}
+
+// Validationf is like the package level Validationf, using the configuration of f.
+func (f *Factory) Validationf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeValidation)
+}
+
+// Input is like the package level Input, using the configuration of f.
+func (f *Factory) Input(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeInput)
+}
+
+// Inputf is like the package level Inputf, using the configuration of f.
+func (f *Factory) Inputf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeInput)
+}
+
+// Duplicate is like the package level Duplicate, using the configuration of f.
+func (f *Factory) Duplicate(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeDuplicate)
+}
+
+// Duplicatef is like the package level Duplicatef, using the configuration of f.
+func (f *Factory) Duplicatef(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeDuplicate)
+}
+
+// Unauthenticated is like the package level Unauthenticated, using the configuration of f.
+func (f *Factory) Unauthenticated(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeUnauthenticated)
+}
+
+// Unauthenticatedf is like the package level Unauthenticatedf, using the configuration of f.
+func (f *Factory) Unauthenticatedf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeUnauthenticated)
+}
+
+// NoPermission is like the package level NoPermission, using the configuration of f.
+func (f *Factory) NoPermission(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeNoPermission)
+}
+
+// NoPermissionf is like the package level NoPermissionf, using the configuration of f.
+func (f *Factory) NoPermissionf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeNoPermission)
+}
+
+// Empty is like the package level Empty, using the configuration of f.
+func (f *Factory) Empty(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeEmpty)
+}
+
+// Emptyf is like the package level Emptyf, using the configuration of f.
+func (f *Factory) Emptyf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeEmpty)
+}
+
+// NotFound is like the package level NotFound, using the configuration of f.
+func (f *Factory) NotFound(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeNotFound)
+}
+
+// NotFoundf is like the package level NotFoundf, using the configuration of f.
+func (f *Factory) NotFoundf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeNotFound)
+}
+
+// LimitExceeded is like the package level LimitExceeded, using the configuration of f.
+func (f *Factory) LimitExceeded(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeLimitExceeded)
+}
+
+// LimitExceededf is like the package level LimitExceededf, using the configuration of f.
+func (f *Factory) LimitExceededf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeLimitExceeded)
+}
+
+// SubscriptionExpired is like the package level SubscriptionExpired, using the configuration of f.
+func (f *Factory) SubscriptionExpired(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeSubscriptionExpired)
+}
+
+// SubscriptionExpiredf is like the package level SubscriptionExpiredf, using the configuration of f.
+func (f *Factory) SubscriptionExpiredf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeSubscriptionExpired)
+}
+
+// Unavailable is like the package level Unavailable, using the configuration of f.
+func (f *Factory) Unavailable(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeUnavailable)
+}
+
+// Unavailablef is like the package level Unavailablef, using the configuration of f.
+func (f *Factory) Unavailablef(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeUnavailable)
+}
+
+// Timeout is like the package level Timeout, using the configuration of f.
+func (f *Factory) Timeout(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeTimeout)
+}
+
+// Timeoutf is like the package level Timeoutf, using the configuration of f.
+func (f *Factory) Timeoutf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeTimeout)
+}
+
+// Canceled is like the package level Canceled, using the configuration of f.
+func (f *Factory) Canceled(message string) error {
+	return f.newErr(context.Background(), message, template{}, TypeCanceled)
+}
+
+// Canceledf is like the package level Canceledf, using the configuration of f.
+func (f *Factory) Canceledf(format string, args ...interface{}) error {
+	return f.newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeCanceled)
+}
@@ -0,0 +1,225 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+func TestNewFactoryDefaults(t *testing.T) {
+	cfg := NewFactory(Config{}).Config()
+	if cfg.DefaultType != TypeInternal || cfg.Bundle == nil || cfg.Language != "en" || cfg.Stack.Depth != 32 || cfg.Stack.Origin ||
+		cfg.IncidentHeader != "X-Incident-Id" || cfg.Mode != Standard {
+		t.Errorf("Config: got %+v", cfg)
+	}
+}
+
+func TestFactoryDefaultType(t *testing.T) {
+	f := NewFactory(Config{DefaultType: TypeUnavailable})
+
+	tests := []struct {
+		err  error
+		want Typer
+	}{
+		{f.New("x"), TypeUnavailable},
+		{f.Errorf("x %d", 1), TypeUnavailable},
+		{f.Wrap(io.EOF, "x"), TypeUnavailable},
+		{f.WithMessage(io.EOF, "x"), TypeUnavailable},
+		{f.Wrap(NotFound("x"), "y"), TypeNotFound},
+		{f.NotFound("x"), TypeNotFound},
+		{New("x"), TypeInternal},
+		{Wrap(io.EOF, "x"), TypeInternal},
+	}
+	for _, tt := range tests {
+		if !HasType(tt.err, tt.want) {
+			t.Errorf("%v: want type %v", tt.err, tt.want)
+		}
+	}
+	if code, _ := GetAPIError(f.New("x")); code != TypeUnavailable.HTTPStatusCode() {
+		t.Errorf("GetAPIError: got %d", code)
+	}
+
+	err := f.NewI18n(nil, &i18n.LocalizeConfig{MessageID: "x"})
+	defer SetDefault(Default())
+	SetDefaultType(TypeNotFound)
+	if !HasType(err, TypeUnavailable) {
+		t.Errorf("NewI18n: want type %v", TypeUnavailable)
+	}
+}
+
+func TestFactoryClassifiers(t *testing.T) {
+	timeouts := func(err error) (Typer, bool) {
+		return TypeTimeout, Is(err, context.DeadlineExceeded)
+	}
+	f := NewFactory(Config{Classifiers: []Classifier{timeouts}})
+
+	if err := f.Wrap(context.DeadlineExceeded, "x"); !HasType(err, TypeTimeout) {
+		t.Errorf("Wrap: want type %v", TypeTimeout)
+	}
+	if err := f.Wrap(io.EOF, "x"); !HasType(err, TypeInternal) {
+		t.Errorf("Wrap unclassified: want type %v", TypeInternal)
+	}
+
+	defer SetDefault(Default())
+	SetDefault(f)
+	if code, _ := GetAPIError(context.DeadlineExceeded); code != TypeTimeout.HTTPStatusCode() {
+		t.Errorf("GetAPIError: got %d", code)
+	}
+}
+
+func TestFactoryHooks(t *testing.T) {
+	var got []error
+	f := NewFactory(Config{Hooks: []Hook{func(ctx context.Context, err error) { got = append(got, err) }}})
+
+	err := f.New("x")
+	New("not hooked")
+	wrapped := f.Wrap(err, "y")
+	if len(got) != 2 || got[0] != err || got[1] != wrapped {
+		t.Errorf("hooks: got %v", got)
+	}
+}
+
+func TestFactoryExtractors(t *testing.T) {
+	type userKey struct{}
+	f := NewFactory(Config{Extractors: []*FieldExtractor{{Key: "user_id", Extract: ContextValue(userKey{})}}})
+	ctx := context.WithValue(context.Background(), userKey{}, 7)
+
+	if got := Fields(f.NewCtx(ctx, "x")); len(got) != 1 || got[0] != (Field{"user_id", 7}) {
+		t.Errorf("NewCtx: got fields %v", got)
+	}
+	if got := Fields(NewCtx(ctx, "x")); len(got) != 0 {
+		t.Errorf("default Factory: got fields %v", got)
+	}
+}
+
+func TestFactoryStack(t *testing.T) {
+	f := NewFactory(Config{Stack: StackOptions{Depth: 1, Origin: true}})
+
+	err := f.New("x")
+	st := err.(interface{ StackTrace() StackTrace }).StackTrace()
+	if len(st) != 1 {
+		t.Fatalf("StackTrace: got %d frames, want 1", len(st))
+	}
+	testFormatRegexp(t, 0, st[0], "%n", "TestFactoryStack")
+	if _, ok := Timestamp(err); !ok {
+		t.Errorf("Timestamp: no origin recorded")
+	}
+	if _, ok := Timestamp(New("x")); ok {
+		t.Errorf("Timestamp: origin recorded by the default Factory")
+	}
+}
+
+func TestFactoryBundle(t *testing.T) {
+	b := i18n.NewBundle(language.English)
+	b.AddMessages(language.German, &i18n.Message{ID: "user_not_found", Other: "Benutzer nicht gefunden"})
+	f := NewFactory(Config{Bundle: b, Language: "de"})
+
+	err := f.NewI18n(TypeNotFound, &i18n.LocalizeConfig{MessageID: "user_not_found"})
+	if got := err.Error(); got != "Benutzer nicht gefunden" {
+		t.Errorf("Error: got %q", got)
+	}
+	if msg, ok := localize(err, "de"); !ok || msg != "Benutzer nicht gefunden" {
+		t.Errorf("localize: got %q, %v", msg, ok)
+	}
+	if _, ok := localize(NewI18n(TypeNotFound, &i18n.LocalizeConfig{MessageID: "user_not_found"}), "de"); ok {
+		t.Errorf("localize: the default Factory used the bundle of another")
+	}
+}
+
+func TestSetDefaultTypeConcurrent(t *testing.T) {
+	defer SetDefault(Default())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetDefaultType(TypeUnavailable)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := New("x"); !HasType(err, TypeInternal) && !HasType(err, TypeUnavailable) {
+					t.Errorf("New: got type %v", getErrType(err))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFactoryHTTP(t *testing.T) {
+	var logged error
+	f := NewFactory(Config{
+		Mode:             Production,
+		IncidentHeader:   "X-Trace",
+		RequestIDHeaders: []string{"X-Req"},
+		HandlerLog:       func(r *http.Request, err error, detail string) { logged = err },
+	})
+
+	rec := httptest.NewRecorder()
+	f.WriteHTTP(rec, Wrap(NotFound("x"), "y"))
+	if got := rec.Body.String(); got != "y\n" {
+		t.Errorf("WriteHTTP: got %q, want %q", got, "y\n")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Req", "req-1")
+	rec = httptest.NewRecorder()
+	Handler{
+		Func:   func(w http.ResponseWriter, r *http.Request) error { return Internal("disk full") },
+		Writer: HTTPWriter{Factory: f},
+	}.ServeHTTP(rec, r)
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body["message"] != DefaultMessage || body["correlation_id"] != "req-1" || rec.Header().Get("X-Trace") == "" {
+		t.Errorf("Handler: got %v %v", rec.Header(), body)
+	}
+	if logged == nil {
+		t.Errorf("Handler: HandlerLog not called")
+	}
+}
+
+func TestFactoryClient(t *testing.T) {
+	f := NewFactory(Config{
+		MaxBodySnippet:   4,
+		RequestIDHeaders: []string{"X-Req"},
+		Retry:            RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		Fingerprint:      FingerprintOptions{IncludeTemplate: true},
+	})
+
+	resp := &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{"X-Req": {"req-1"}}, Body: io.NopCloser(strings.NewReader("upstream down"))}
+	var ce *ClientError
+	if !As(f.FromHTTP(nil, resp, nil), &ce) || ce.Body != "upst" || ce.RequestID != "req-1" {
+		t.Errorf("FromHTTP: got %+v", ce)
+	}
+	if st := ce.StackTrace(); len(st) == 0 || !strings.Contains(fmt.Sprintf("%n", st[0]), "TestFactoryClient") {
+		t.Errorf("FromHTTP: stack trace does not start at the caller")
+	}
+
+	calls := 0
+	f.Retry(context.Background(), RetryPolicy{}, func(ctx context.Context) error {
+		calls++
+		return Unavailable("x")
+	})
+	if calls != 2 {
+		t.Errorf("Retry: got %d calls, want 2", calls)
+	}
+
+	a, b := Errorf("user %d", 1), Errorf("order %d", 1)
+	if Fingerprint(a) != Fingerprint(b) || f.Fingerprint(a) == f.Fingerprint(b) {
+		t.Errorf("Fingerprint: want only the options of f to include the template")
+	}
+}
@@ -0,0 +1,268 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Field is a named value attached to an error, such as the ID of the request
+// during which the error was created.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Extractor returns the value of a field carried by ctx. The boolean is false
+// if ctx does not carry the value.
+type Extractor func(ctx context.Context) (value interface{}, ok bool)
+
+// FieldExtractor extracts the field Key from a context, see Config.
+type FieldExtractor struct {
+	Key     string
+	Extract Extractor
+}
+
+// RegisterExtractor adds an extractor of the field key to the Extractors of
+// the default Factory, used by NewCtx, WrapCtx and the other constructors
+// taking a context, and returns a function removing it. Fields are attached
+// in the order their extractors were registered.
+func RegisterExtractor(key string, fn Extractor) (remove func()) {
+	e := &FieldExtractor{Key: key, Extract: fn}
+	updateDefault(func(cfg *Config) { cfg.Extractors = append(cfg.Extractors, e) })
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { updateDefault(func(cfg *Config) { cfg.Extractors = removeExtractor(cfg.Extractors, e) }) })
+	}
+}
+
+func removeExtractor(es []*FieldExtractor, e *FieldExtractor) []*FieldExtractor {
+	kept := make([]*FieldExtractor, 0, len(es))
+	for _, o := range es {
+		if o != e {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// ContextValue returns an Extractor returning ctx.Value(key), if it is not nil.
+func ContextValue(key interface{}) Extractor {
+	return func(ctx context.Context) (interface{}, bool) {
+		v := ctx.Value(key)
+		return v, v != nil
+	}
+}
+
+// extract returns the fields extracted from ctx by the Extractors of f.
+func (f *Factory) extract(ctx context.Context) []Field {
+	if len(f.cfg.Extractors) == 0 || ctx == nil {
+		return nil
+	}
+	var fields []Field
+	for _, e := range f.cfg.Extractors {
+		if v, ok := e.Extract(ctx); ok {
+			fields = append(fields, Field{e.Key, v})
+		}
+	}
+	return fields
+}
+
+// withContext annotates err with the fields extracted from ctx, if any.
+func (f *Factory) withContext(ctx context.Context, err error) error {
+	fields := f.extract(ctx)
+	if len(fields) == 0 {
+		return err
+	}
+	return withMetadata(err, fieldList(fields))
+}
+
+// fieldList is the metadata added by withContext.
+type fieldList []Field
+
+func (l fieldList) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		io.WriteString(s, "\nfields:")
+		for _, f := range l {
+			fmt.Fprintf(s, " %s=%v", f.Key, f.Value)
+		}
+	}
+}
+
+// Fields returns the fields attached to the errors in err's chain. If several
+// errors carry a field with the same key, the outermost value is returned.
+func Fields(err error) []Field {
+	var fields []Field
+	seen := make(map[string]bool)
+	for err != nil {
+		if l, ok := metaOf(err).(fieldList); ok {
+			for _, f := range l {
+				if !seen[f.Key] {
+					seen[f.Key] = true
+					fields = append(fields, f)
+				}
+			}
+		}
+		err = Unwrap(err)
+	}
+	return fields
+}
+
+// NewCtx is like New, and attaches the fields extracted from ctx by the
+// registered extractors.
+func NewCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, nil)
+}
+
+// ErrorfCtx is like Errorf, and attaches the fields extracted from ctx by the
+// registered extractors.
+func ErrorfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, nil)
+}
+
+// WrapCtx is like Wrap, and attaches the fields extracted from ctx by the
+// registered extractors. If err is nil, WrapCtx returns nil.
+func WrapCtx(ctx context.Context, err error, message string) error {
+	return Default().wrap(ctx, err, message, template{}, nil)
+}
+
+// WrapfCtx is like Wrapf, and attaches the fields extracted from ctx by the
+// registered extractors. If err is nil, WrapfCtx returns nil.
+func WrapfCtx(ctx context.Context, err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return Default().wrap(ctx, err, fmt.Sprintf(format, args...), template{format, args}, nil)
+}
+
+// InternalCtx is like Internal, and attaches the fields extracted from ctx.
+func InternalCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeInternal)
+}
+
+// InternalfCtx is like Internalf, and attaches the fields extracted from ctx.
+func InternalfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeInternal)
+}
+
+// ValidationCtx is like Validation, and attaches the fields extracted from ctx.
+func ValidationCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeValidation)
+}
+
+// ValidationfCtx is like Validationf, and attaches the fields extracted from ctx.
+func ValidationfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeValidation)
+}
+
+// InputCtx is like Input, and attaches the fields extracted from ctx.
+func InputCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeInput)
+}
+
+// InputfCtx is like Inputf, and attaches the fields extracted from ctx.
+func InputfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeInput)
+}
+
+// DuplicateCtx is like Duplicate, and attaches the fields extracted from ctx.
+func DuplicateCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeDuplicate)
+}
+
+// DuplicatefCtx is like Duplicatef, and attaches the fields extracted from ctx.
+func DuplicatefCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeDuplicate)
+}
+
+// UnauthenticatedCtx is like Unauthenticated, and attaches the fields extracted from ctx.
+func UnauthenticatedCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeUnauthenticated)
+}
+
+// UnauthenticatedfCtx is like Unauthenticatedf, and attaches the fields extracted from ctx.
+func UnauthenticatedfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeUnauthenticated)
+}
+
+// NoPermissionCtx is like NoPermission, and attaches the fields extracted from ctx.
+func NoPermissionCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeNoPermission)
+}
+
+// NoPermissionfCtx is like NoPermissionf, and attaches the fields extracted from ctx.
+func NoPermissionfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeNoPermission)
+}
+
+// EmptyCtx is like Empty, and attaches the fields extracted from ctx.
+func EmptyCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeEmpty)
+}
+
+// EmptyfCtx is like Emptyf, and attaches the fields extracted from ctx.
+func EmptyfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeEmpty)
+}
+
+// NotFoundCtx is like NotFound, and attaches the fields extracted from ctx.
+func NotFoundCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeNotFound)
+}
+
+// NotFoundfCtx is like NotFoundf, and attaches the fields extracted from ctx.
+func NotFoundfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeNotFound)
+}
+
+// LimitExceededCtx is like LimitExceeded, and attaches the fields extracted from ctx.
+func LimitExceededCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeLimitExceeded)
+}
+
+// LimitExceededfCtx is like LimitExceededf, and attaches the fields extracted from ctx.
+func LimitExceededfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeLimitExceeded)
+}
+
+// SubscriptionExpiredCtx is like SubscriptionExpired, and attaches the fields extracted from ctx.
+func SubscriptionExpiredCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeSubscriptionExpired)
+}
+
+// SubscriptionExpiredfCtx is like SubscriptionExpiredf, and attaches the fields extracted from ctx.
+func SubscriptionExpiredfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeSubscriptionExpired)
+}
+
+// UnavailableCtx is like Unavailable, and attaches the fields extracted from ctx.
+func UnavailableCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeUnavailable)
+}
+
+// UnavailablefCtx is like Unavailablef, and attaches the fields extracted from ctx.
+func UnavailablefCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeUnavailable)
+}
+
+// TimeoutCtx is like Timeout, and attaches the fields extracted from ctx.
+func TimeoutCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeTimeout)
+}
+
+// TimeoutfCtx is like Timeoutf, and attaches the fields extracted from ctx.
+func TimeoutfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeTimeout)
+}
+
+// CanceledCtx is like Canceled, and attaches the fields extracted from ctx.
+func CanceledCtx(ctx context.Context, message string) error {
+	return Default().newErr(ctx, message, template{}, TypeCanceled)
+}
+
+// CanceledfCtx is like Canceledf, and attaches the fields extracted from ctx.
+func CanceledfCtx(ctx context.Context, format string, args ...interface{}) error {
+	return Default().newErr(ctx, fmt.Sprintf(format, args...), template{format, args}, TypeCanceled)
+}
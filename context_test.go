@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+)
+
+type ctxKey string
+
+func withExtractors(t *testing.T) context.Context {
+	removeRequest := RegisterExtractor("request_id", ContextValue(ctxKey("request")))
+	removeUser := RegisterExtractor("user_id", ContextValue(ctxKey("user")))
+	t.Cleanup(func() {
+		removeRequest()
+		removeUser()
+	})
+	ctx := context.WithValue(context.Background(), ctxKey("request"), "req-1")
+	return context.WithValue(ctx, ctxKey("user"), 42)
+}
+
+func TestNewCtx(t *testing.T) {
+	ctx := withExtractors(t)
+
+	tests := []struct {
+		err  error
+		want []Field
+	}{
+		{NewCtx(ctx, "x"), []Field{{"request_id", "req-1"}, {"user_id", 42}}},
+		{NewCtx(context.Background(), "x"), nil},
+		{NewCtx(context.WithValue(context.Background(), ctxKey("user"), 7), "x"), []Field{{"user_id", 7}}},
+		{WrapCtx(ctx, io.EOF, "x"), []Field{{"request_id", "req-1"}, {"user_id", 42}}},
+		{Wrap(NotFoundCtx(ctx, "x"), "y"), []Field{{"request_id", "req-1"}, {"user_id", 42}}},
+		{New("x"), nil},
+	}
+	for i, tt := range tests {
+		got := Fields(tt.err)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("test %d: Fields: got %v, want %v", i+1, got, tt.want)
+		}
+	}
+}
+
+func TestFieldsOutermostWins(t *testing.T) {
+	ctx := withExtractors(t)
+	inner := NewCtx(ctx, "x")
+	outer := WrapCtx(context.WithValue(ctx, ctxKey("request"), "req-2"), inner, "y")
+	got := Fields(outer)
+	want := []Field{{"request_id", "req-2"}, {"user_id", 42}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Fields: got %v, want %v", got, want)
+	}
+}
+
+func TestCtxTypes(t *testing.T) {
+	ctx := withExtractors(t)
+	tests := []struct {
+		err  error
+		want Typer
+	}{
+		{NewCtx(ctx, "x"), Default().Config().DefaultType},
+		{InternalCtx(ctx, "x"), TypeInternal},
+		{ValidationCtx(ctx, "x"), TypeValidation},
+		{InputCtx(ctx, "x"), TypeInput},
+		{DuplicateCtx(ctx, "x"), TypeDuplicate},
+		{UnauthenticatedCtx(ctx, "x"), TypeUnauthenticated},
+		{NoPermissionCtx(ctx, "x"), TypeNoPermission},
+		{EmptyCtx(ctx, "x"), TypeEmpty},
+		{NotFoundCtx(ctx, "x"), TypeNotFound},
+		{LimitExceededCtx(ctx, "x"), TypeLimitExceeded},
+		{SubscriptionExpiredCtx(ctx, "x"), TypeSubscriptionExpired},
+		{UnavailableCtx(ctx, "x"), TypeUnavailable},
+		{TimeoutCtx(ctx, "x"), TypeTimeout},
+		{CanceledCtx(ctx, "x"), TypeCanceled},
+		{WrapCtx(ctx, NotFound("x"), "y"), TypeNotFound},
+		{ErrorfCtx(ctx, "x %d", 1), Default().Config().DefaultType},
+		{InternalfCtx(ctx, "x %d", 1), TypeInternal},
+		{ValidationfCtx(ctx, "x %d", 1), TypeValidation},
+		{InputfCtx(ctx, "x %d", 1), TypeInput},
+		{DuplicatefCtx(ctx, "x %d", 1), TypeDuplicate},
+		{UnauthenticatedfCtx(ctx, "x %d", 1), TypeUnauthenticated},
+		{NoPermissionfCtx(ctx, "x %d", 1), TypeNoPermission},
+		{EmptyfCtx(ctx, "x %d", 1), TypeEmpty},
+		{NotFoundfCtx(ctx, "x %d", 1), TypeNotFound},
+		{LimitExceededfCtx(ctx, "x %d", 1), TypeLimitExceeded},
+		{SubscriptionExpiredfCtx(ctx, "x %d", 1), TypeSubscriptionExpired},
+		{UnavailablefCtx(ctx, "x %d", 1), TypeUnavailable},
+		{TimeoutfCtx(ctx, "x %d", 1), TypeTimeout},
+		{CanceledfCtx(ctx, "x %d", 1), TypeCanceled},
+		{WrapfCtx(ctx, NotFound("x"), "y %d", 1), TypeNotFound},
+	}
+	for i, tt := range tests {
+		if got := getErrType(tt.err); got != tt.want {
+			t.Errorf("test %d: type: got %v, want %v", i+1, got, tt.want)
+		}
+		if code, _ := GetAPIError(tt.err); code != tt.want.HTTPStatusCode() {
+			t.Errorf("test %d: GetAPIError: got %d, want %d", i+1, code, tt.want.HTTPStatusCode())
+		}
+	}
+	for i, tt := range tests[15:] {
+		if got := Template(tt.err); got != "x %d" && got != "y %d: x" {
+			t.Errorf("test %d: Template: got %q", i+16, got)
+		}
+		if len(Fields(tt.err)) == 0 {
+			t.Errorf("test %d: no fields attached", i+16)
+		}
+	}
+	if WrapCtx(ctx, nil, "x") != nil || WrapfCtx(ctx, nil, "x") != nil {
+		t.Errorf("WrapCtx(nil): got non-nil")
+	}
+}
+
+func TestFieldsFormat(t *testing.T) {
+	ctx := withExtractors(t)
+	err := NewCtx(ctx, "error")
+	if got := fmt.Sprintf("%v", err); got != "error" {
+		t.Errorf("%%v: got %q", got)
+	}
+	want := "error\n" +
+		"github.com/bynil/errors.TestFieldsFormat\n" +
+		"\t.+/github.com/bynil/errors/context_test.go:\\d+\n" +
+		"(?s:.*)\nfields: request_id=req-1 user_id=42$"
+	if got := fmt.Sprintf("%+v", err); !regexp.MustCompile(want).MatchString(got) {
+		t.Errorf("%%+v:\n got %q\n want %q", got, want)
+	}
+}
+
+func TestFieldsMarshalJSON(t *testing.T) {
+	ctx := withExtractors(t)
+	b, err := json.Marshal(WrapCtx(ctx, io.EOF, "read"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Message string
+		Fields  map[string]interface{}
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Message != "read: EOF" || got.Fields["request_id"] != "req-1" || got.Fields["user_id"] != 42.0 {
+		t.Errorf("json.Marshal: got %s", b)
+	}
+}
+
+func TestCtxHook(t *testing.T) {
+	ctx := withExtractors(t)
+	var got context.Context
+	defer OnCreate(func(ctx context.Context, err error) { got = ctx })()
+	NotFoundCtx(ctx, "x")
+	if got != ctx {
+		t.Errorf("hook: got context %v, want %v", got, ctx)
+	}
+}
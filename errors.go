@@ -93,6 +93,7 @@
 package errors
 
 import (
+	"context"
 	"fmt"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"io"
@@ -101,37 +102,27 @@ import (
 // New returns an error with the supplied message.
 // New also records the stack trace at the point it was called.
 func New(message string) error {
-	return &fundamental{
-		msg:   message,
-		stack: callers(),
-	}
+	return Default().newErr(context.Background(), message, template{}, nil)
 }
 
 func NewI18n(eType Typer, lc *i18n.LocalizeConfig) error {
-	err := &localization{
-		lc:    lc,
-		eType: eType,
-		stack: callers(),
-	}
-	err.msg, _ = localizer.Localize(lc)
-	return err
+	return Default().newI18n(eType, lc)
 }
 
 // Errorf formats according to a format specifier and returns the string
 // as a value that satisfies error.
 // Errorf also records the stack trace at the point it was called.
 func Errorf(format string, args ...interface{}) error {
-	return &fundamental{
-		msg:   fmt.Sprintf(format, args...),
-		stack: callers(),
-	}
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, nil)
 }
 
 // fundamental is an error that has a message and a stack, but no caller.
 type fundamental struct {
 	msg   string
+	tmpl  template
 	eType Typer
 	*stack
+	*origin
 }
 
 func (f *fundamental) Error() string { return f.msg }
@@ -141,6 +132,7 @@ func (f *fundamental) Format(s fmt.State, verb rune) {
 	case 'v':
 		if s.Flag('+') {
 			io.WriteString(s, f.msg)
+			f.origin.Format(s, verb)
 			f.stack.Format(s, verb)
 			return
 		}
@@ -154,7 +146,7 @@ func (f *fundamental) Format(s fmt.State, verb rune) {
 
 func (f *fundamental) Type() Typer {
 	if f.eType == nil {
-		return defaultErrType
+		return Default().cfg.DefaultType
 	}
 	return f.eType
 }
@@ -163,21 +155,20 @@ func (f *fundamental) APIError() (int, string) {
 	return f.Type().HTTPStatusCode(), f.msg
 }
 
+func (f *fundamental) messageTemplate() (string, []interface{}) {
+	return f.tmpl.resolve(f.msg)
+}
+
 // WithStack annotates err with a stack trace at the point WithStack was called.
 // If err is nil, WithStack returns nil.
 func WithStack(err error) error {
-	if err == nil {
-		return nil
-	}
-	return &withStack{
-		err,
-		callers(),
-	}
+	return Default().withStack(err)
 }
 
 type withStack struct {
 	error
 	*stack
+	*origin
 }
 
 func (w *withStack) Cause() error { return w.error }
@@ -190,6 +181,7 @@ func (w *withStack) Format(s fmt.State, verb rune) {
 	case 'v':
 		if s.Flag('+') {
 			fmt.Fprintf(s, "%+v", w.Cause())
+			w.origin.Format(s, verb)
 			w.stack.Format(s, verb)
 			return
 		}
@@ -205,40 +197,22 @@ func (w *withStack) APIError() (int, string) {
 	if w, ok := w.error.(APIError); ok {
 		return w.APIError()
 	}
-	return defaultErrType.HTTPStatusCode(), w.Error()
+	return getErrType(w.error).HTTPStatusCode(), w.Error()
+}
+
+func (w *withStack) messageTemplate() (string, []interface{}) {
+	return templateOf(w.error)
 }
 
 // Wrap returns an error annotating err with a stack trace
 // at the point Wrap is called, and the supplied message.
 // If err is nil, Wrap returns nil.
 func Wrap(err error, message string) error {
-	if err == nil {
-		return nil
-	}
-	err = &withMessage{
-		cause: err,
-		msg:   message,
-		eType: getErrType(err),
-	}
-	return &withStack{
-		err,
-		callers(),
-	}
+	return Default().wrap(context.Background(), err, message, template{}, nil)
 }
 
 func WrapType(err error, eType Typer, message string) error {
-	if err == nil {
-		return nil
-	}
-	err = &withMessage{
-		cause: err,
-		msg:   message,
-		eType: eType,
-	}
-	return &withStack{
-		err,
-		callers(),
-	}
+	return Default().wrap(context.Background(), err, message, template{}, eType)
 }
 
 // Wrapf returns an error annotating err with a stack trace
@@ -248,43 +222,20 @@ func Wrapf(err error, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
-	err = &withMessage{
-		cause: err,
-		msg:   fmt.Sprintf(format, args...),
-		eType: getErrType(err),
-	}
-	return &withStack{
-		err,
-		callers(),
-	}
+	return Default().wrap(context.Background(), err, fmt.Sprintf(format, args...), template{format, args}, nil)
 }
 
 func WrapTypef(err error, eType Typer, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
-	err = &withMessage{
-		cause: err,
-		msg:   fmt.Sprintf(format, args...),
-		eType: eType,
-	}
-	return &withStack{
-		err,
-		callers(),
-	}
+	return Default().wrap(context.Background(), err, fmt.Sprintf(format, args...), template{format, args}, eType)
 }
 
 // WithMessage annotates err with a new message.
 // If err is nil, WithMessage returns nil.
 func WithMessage(err error, message string) error {
-	if err == nil {
-		return nil
-	}
-	return &withMessage{
-		cause: err,
-		msg:   message,
-		eType: getErrType(err),
-	}
+	return Default().withMessage(err, message, template{})
 }
 
 // WithMessagef annotates err with the format specifier.
@@ -293,16 +244,13 @@ func WithMessagef(err error, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
-	return &withMessage{
-		cause: err,
-		msg:   fmt.Sprintf(format, args...),
-		eType: getErrType(err),
-	}
+	return Default().withMessage(err, fmt.Sprintf(format, args...), template{format, args})
 }
 
 type withMessage struct {
 	cause error
 	msg   string
+	tmpl  template
 	eType Typer
 }
 
@@ -328,7 +276,7 @@ func (w *withMessage) Format(s fmt.State, verb rune) {
 
 func (w *withMessage) Type() Typer {
 	if w.eType == nil {
-		return defaultErrType
+		return Default().cfg.DefaultType
 	}
 	return w.eType
 }
@@ -337,11 +285,22 @@ func (w *withMessage) APIError() (int, string) {
 	return w.Type().HTTPStatusCode(), w.Error()
 }
 
+func (w *withMessage) messageTemplate() (string, []interface{}) {
+	format, args := w.tmpl.resolve(w.msg)
+	causeFormat, causeArgs := templateOf(w.cause)
+	if causeArgs != nil {
+		args = append(append([]interface{}(nil), args...), causeArgs...)
+	}
+	return format + ": " + causeFormat, args
+}
+
 type localization struct {
-	lc    *i18n.LocalizeConfig
-	eType Typer
-	msg   string
+	lc     *i18n.LocalizeConfig
+	eType  Typer
+	msg    string
+	bundle *i18n.Bundle
 	*stack
+	*origin
 }
 
 func (l *localization) Error() string { return l.msg }
@@ -351,6 +310,7 @@ func (l *localization) Format(s fmt.State, verb rune) {
 	case 'v':
 		if s.Flag('+') {
 			io.WriteString(s, l.msg)
+			l.origin.Format(s, verb)
 			l.stack.Format(s, verb)
 			return
 		}
@@ -367,9 +327,6 @@ func (l *localization) LocalizeConfig() (lc *i18n.LocalizeConfig) {
 }
 
 func (l *localization) Type() Typer {
-	if l.eType == nil {
-		return defaultErrType
-	}
 	return l.eType
 }
 
@@ -0,0 +1,91 @@
+package errors
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+)
+
+// FingerprintOptions selects the inputs used to compute an error fingerprint.
+type FingerprintOptions struct {
+	// IgnoreLines leaves line numbers out of the fingerprint, so that the
+	// fingerprint survives unrelated edits to the same source files.
+	IgnoreLines bool
+	// IgnoreChain leaves the Go types of the errors in the chain out of the fingerprint.
+	IgnoreChain bool
+	// IncludeType adds the Typer of err, as returned by Type(), to the fingerprint.
+	IncludeType bool
+	// IncludeTemplate adds the message template of err, as returned by Template,
+	// to the fingerprint. Format arguments are never included.
+	IncludeTemplate bool
+	// MaxFrames limits the number of innermost frames used, zero means all frames.
+	MaxFrames int
+}
+
+// SetFingerprintOptions sets the options used by Fingerprint, the
+// Fingerprint option of the default Factory.
+func SetFingerprintOptions(o FingerprintOptions) {
+	updateDefault(func(cfg *Config) { cfg.Fingerprint = o })
+}
+
+// Fingerprint returns a stable hash identifying the origin of err, computed
+// using the Fingerprint options of the default Factory. Errors created at the same place by the
+// same chain of wrappers share a fingerprint regardless of their messages,
+// the host they occurred on or the path the binary was built in.
+// Fingerprint returns the empty string if err is nil.
+func Fingerprint(err error) string {
+	return Default().Fingerprint(err)
+}
+
+// Fingerprint is like the Fingerprint function, using the Fingerprint
+// options of f.
+func (f *Factory) Fingerprint(err error) string {
+	return f.cfg.Fingerprint.Fingerprint(err)
+}
+
+// Fingerprint returns the fingerprint of err computed from the inputs selected by o.
+//
+// The inputs are the Go types of every error in the chain, the function
+// names and line numbers of the innermost stack trace found in the chain and,
+// optionally, the Typer and message template of err. Formatted messages are
+// never part of the fingerprint, nor are the wrappers which only attach
+// metadata, such as fields, incident IDs, origins, severities or headers.
+func (o FingerprintOptions) Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	h := fnv.New64a()
+	o.write(h, err)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+func (o FingerprintOptions) write(w io.Writer, err error) {
+	if o.IncludeType {
+		et := getErrType(err)
+		fmt.Fprintf(w, "type %T %v\n", et, et)
+	}
+	if o.IncludeTemplate {
+		fmt.Fprintf(w, "template %q\n", Template(err))
+	}
+	if !o.IgnoreChain {
+		for e := err; e != nil; e = Unwrap(e) {
+			if _, ok := e.(*withMeta); ok {
+				continue
+			}
+			fmt.Fprintf(w, "error %T\n", e)
+		}
+	}
+	st := stackOf(err)
+	if o.MaxFrames > 0 && len(st) > o.MaxFrames {
+		st = st[:o.MaxFrames]
+	}
+	for _, f := range st {
+		sym := f.symbol()
+		if o.IgnoreLines {
+			fmt.Fprintf(w, "frame %s\n", sym.name)
+			continue
+		}
+		fmt.Fprintf(w, "frame %s:%d\n", sym.name, sym.line)
+	}
+}
@@ -0,0 +1,80 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func fingerprintAt(id int) error {
+	return Wrapf(NotFoundf("user %d not found", id), "load user %d", id)
+}
+
+func TestFingerprint(t *testing.T) {
+	if got := Fingerprint(nil); got != "" {
+		t.Errorf("Fingerprint(nil): got %q, want empty", got)
+	}
+
+	a, b := fingerprintAt(42), fingerprintAt(43)
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Errorf("Fingerprint differs for errors created at the same place: %q != %q", Fingerprint(a), Fingerprint(b))
+	}
+
+	c := Wrapf(NotFoundf("user %d not found", 42), "load user %d", 42)
+	if Fingerprint(a) == Fingerprint(c) {
+		t.Errorf("Fingerprint equal for errors created at different places: %q", Fingerprint(a))
+	}
+
+	if Fingerprint(io.EOF) == Fingerprint(fmt.Errorf("wrapped: %w", io.EOF)) {
+		t.Errorf("Fingerprint equal for different chains")
+	}
+}
+
+func TestFingerprintMetadata(t *testing.T) {
+	type requestKey struct{}
+	defer RegisterExtractor("request_id", ContextValue(requestKey{}))()
+	var errs []error
+	for _, ctx := range []context.Context{context.Background(), context.WithValue(context.Background(), requestKey{}, "req-1")} {
+		errs = append(errs, NotFoundCtx(ctx, "no such user"))
+	}
+	want := Fingerprint(errs[0])
+	for i, err := range []error{
+		errs[1],
+		WithIncidentID(WithSeverity(errs[0], SeverityError), "inc-1"),
+		WithOrigin(WithHeader(errs[0], "X-Reason", "test")),
+	} {
+		if got := Fingerprint(err); got != want {
+			t.Errorf("test %d: Fingerprint: got %q, want %q", i+1, got, want)
+		}
+	}
+}
+
+func TestFingerprintOptions(t *testing.T) {
+	x := New("x")
+	y := New("y")
+	noLines := FingerprintOptions{IgnoreLines: true}
+	if Fingerprint(x) == Fingerprint(y) {
+		t.Errorf("Fingerprint equal for errors created on different lines")
+	}
+	if noLines.Fingerprint(x) != noLines.Fingerprint(y) {
+		t.Errorf("Fingerprint with IgnoreLines differs for errors created in the same function")
+	}
+
+	withType := FingerprintOptions{IncludeType: true, IgnoreLines: true}
+	var errs []error
+	for _, et := range []Typer{TypeNotFound, TypeInternal} {
+		errs = append(errs, WrapType(io.EOF, et, "read"))
+	}
+	if Fingerprint(errs[0]) != Fingerprint(errs[1]) {
+		t.Errorf("Fingerprint differs although type is not included")
+	}
+	if withType.Fingerprint(errs[0]) == withType.Fingerprint(errs[1]) {
+		t.Errorf("Fingerprint with IncludeType equal for different types")
+	}
+
+	chainOnly := FingerprintOptions{IgnoreChain: true, MaxFrames: 1}
+	if chainOnly.Fingerprint(x) != chainOnly.Fingerprint(WithStack(x)) {
+		t.Errorf("Fingerprint with IgnoreChain differs for the same innermost stack")
+	}
+}
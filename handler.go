@@ -0,0 +1,234 @@
+package errors
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// LogFunc is called by Handler with every error returned by a handler
+// function, together with the error formatted with %+v.
+type LogFunc func(r *http.Request, err error, detail string)
+
+// SetHandlerLog sets the HandlerLog of the default Factory, the LogFunc
+// used by HandlerFunc and by every Handler without its own Log or Factory.
+func SetHandlerLog(fn LogFunc) {
+	updateDefault(func(cfg *Config) { cfg.HandlerLog = fn })
+}
+
+// Handler is an http.Handler calling Func and writing the error it returns,
+// if any, as the response.
+//
+// The error is written by Writer, which takes the status code from
+// GetAPIError, localizes the message in the languages of the request and
+// renders it in the media type negotiated from the Accept header. Errors
+// with a 5xx status, and those whose message is redacted in Production
+// mode, are logged and written with the same incident ID, see
+// AttachIncidentID. If Func already started the response the error is only
+// logged.
+type Handler struct {
+	Func func(w http.ResponseWriter, r *http.Request) error
+	// Log overrides the HandlerLog of the Factory of Writer.
+	Log    LogFunc
+	Writer HTTPWriter
+}
+
+// HandlerFunc adapts a function returning an error to an http.Handler,
+// see Handler.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Handler{Func: f}.ServeHTTP(w, r)
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := &responseWriter{ResponseWriter: w}
+	err := h.Func(rw.withInterfaces(), r)
+	if err == nil {
+		return
+	}
+	if rw.started {
+		h.log(r, err)
+		return
+	}
+	err = attachIncidentID(r.Context(), err, h.Writer.mode())
+	h.log(r, err)
+	h.Writer.Write(w, r, err)
+}
+
+func (h Handler) log(r *http.Request, err error) {
+	log := h.Log
+	if log == nil {
+		log = h.Writer.factory().cfg.HandlerLog
+	}
+	if log != nil {
+		log(r, err, fmt.Sprintf("%+v", err))
+	}
+}
+
+// responseWriter records whether the response has been started.
+type responseWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.started = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.started = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// flusher, hijacker, pusher and readerFrom implement the optional interfaces
+// of the underlying ResponseWriter of rw, see withInterfaces.
+type (
+	flusher    struct{ rw *responseWriter }
+	hijacker   struct{ rw *responseWriter }
+	pusher     struct{ rw *responseWriter }
+	readerFrom struct{ rw *responseWriter }
+)
+
+func (f flusher) Flush() {
+	f.rw.started = true
+	f.rw.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.rw.started = true
+	return h.rw.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.rw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// ReadFrom uses the ReadFrom of the underlying ResponseWriter, e.g. to send
+// files with sendfile.
+func (r readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	r.rw.started = true
+	return r.rw.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+}
+
+// withInterfaces returns rw as an http.ResponseWriter which implements
+// http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom if, and only
+// if, the underlying ResponseWriter does, so that type assertions for them
+// keep working and fail as they would without rw.
+func (rw *responseWriter) withInterfaces() http.ResponseWriter {
+	var mask int
+	if _, ok := rw.ResponseWriter.(http.Flusher); ok {
+		mask |= 1
+	}
+	if _, ok := rw.ResponseWriter.(http.Hijacker); ok {
+		mask |= 2
+	}
+	if _, ok := rw.ResponseWriter.(http.Pusher); ok {
+		mask |= 4
+	}
+	if _, ok := rw.ResponseWriter.(io.ReaderFrom); ok {
+		mask |= 8
+	}
+	f, h, p, r := flusher{rw}, hijacker{rw}, pusher{rw}, readerFrom{rw}
+	switch mask {
+	case 1:
+		return struct {
+			*responseWriter
+			flusher
+		}{rw, f}
+	case 2:
+		return struct {
+			*responseWriter
+			hijacker
+		}{rw, h}
+	case 3:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+		}{rw, f, h}
+	case 4:
+		return struct {
+			*responseWriter
+			pusher
+		}{rw, p}
+	case 5:
+		return struct {
+			*responseWriter
+			flusher
+			pusher
+		}{rw, f, p}
+	case 6:
+		return struct {
+			*responseWriter
+			hijacker
+			pusher
+		}{rw, h, p}
+	case 7:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			pusher
+		}{rw, f, h, p}
+	case 8:
+		return struct {
+			*responseWriter
+			readerFrom
+		}{rw, r}
+	case 9:
+		return struct {
+			*responseWriter
+			flusher
+			readerFrom
+		}{rw, f, r}
+	case 10:
+		return struct {
+			*responseWriter
+			hijacker
+			readerFrom
+		}{rw, h, r}
+	case 11:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			readerFrom
+		}{rw, f, h, r}
+	case 12:
+		return struct {
+			*responseWriter
+			pusher
+			readerFrom
+		}{rw, p, r}
+	case 13:
+		return struct {
+			*responseWriter
+			flusher
+			pusher
+			readerFrom
+		}{rw, f, p, r}
+	case 14:
+		return struct {
+			*responseWriter
+			hijacker
+			pusher
+			readerFrom
+		}{rw, h, p, r}
+	case 15:
+		return struct {
+			*responseWriter
+			flusher
+			hijacker
+			pusher
+			readerFrom
+		}{rw, f, h, p, r}
+	}
+	return rw
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter { return rw.ResponseWriter }
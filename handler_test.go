@@ -0,0 +1,167 @@
+package errors
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+func serve(h http.Handler, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestHandlerFunc(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return Wrap(NotFound("user not found"), "load profile")
+	})
+
+	rec := serve(h, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status: got %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	var body struct {
+		Status  int
+		Message string
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Status != http.StatusNotFound || body.Message != "load profile: user not found" {
+		t.Errorf("body: got %+v", body)
+	}
+
+	rec = serve(h, http.Header{"Accept": {"application/problem+json"}})
+	if got := rec.Header().Get("Content-Type"); got != MediaTypeProblem {
+		t.Errorf("Content-Type: got %q, want %q", got, MediaTypeProblem)
+	}
+	if !strings.Contains(rec.Body.String(), `"title":"Not Found"`) {
+		t.Errorf("body: got %s", rec.Body)
+	}
+
+	rec = serve(h, http.Header{"Accept": {"text/plain"}})
+	if got := rec.Body.String(); got != "load profile: user not found\n" {
+		t.Errorf("body: got %q", got)
+	}
+}
+
+func TestHandlerNoError(t *testing.T) {
+	rec := serve(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		io.WriteString(w, "ok")
+		return nil
+	}), nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("got %d %q", rec.Code, rec.Body)
+	}
+}
+
+func TestHandlerStartedResponse(t *testing.T) {
+	var logged string
+	h := Handler{
+		Func: func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(http.StatusAccepted)
+			io.WriteString(w, "partial")
+			return Internal("stream broken")
+		},
+		Log: func(r *http.Request, err error, detail string) {
+			logged = detail
+		},
+	}
+	rec := serve(h, nil)
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Errorf("got %d %q, want the started response to be left alone", rec.Code, rec.Body)
+	}
+	if !strings.HasPrefix(logged, "stream broken\ngithub.com/bynil/errors.TestHandlerStartedResponse") {
+		t.Errorf("Log: got %q, want the %%+v formatted error", logged)
+	}
+}
+
+func TestHandlerLog(t *testing.T) {
+	var got error
+	SetHandlerLog(func(r *http.Request, err error, detail string) { got = err })
+	defer SetHandlerLog(nil)
+
+	want := Input("bad json")
+	serve(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error { return want }), nil)
+	if got != want {
+		t.Errorf("SetHandlerLog: logged %v, want %v", got, want)
+	}
+}
+
+func TestHandlerLocalization(t *testing.T) {
+	b := i18n.NewBundle(language.English)
+	b.AddMessages(language.German, &i18n.Message{ID: "NotFound", Other: "nicht gefunden"})
+	SetBundle(b)
+	defer SetBundle(i18n.NewBundle(language.English))
+
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return Wrap(NewI18n(TypeNotFound, &i18n.LocalizeConfig{
+			DefaultMessage: &i18n.Message{ID: "NotFound", Other: "not found"},
+		}), "lookup")
+	})
+	for lang, want := range map[string]string{"de-DE,de;q=0.9": "nicht gefunden\n", "en": "not found\n", "": "not found\n"} {
+		rec := serve(h, http.Header{"Accept": {"text/plain"}, "Accept-Language": {lang}})
+		if rec.Code != http.StatusNotFound || rec.Body.String() != want {
+			t.Errorf("Accept-Language %q: got %d %q, want %q", lang, rec.Code, rec.Body, want)
+		}
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestHandlerResponseWriterInterfaces(t *testing.T) {
+	rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	Handler{Func: func(w http.ResponseWriter, r *http.Request) error {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Errorf("ResponseWriter does not implement http.Flusher")
+		}
+		if _, ok := w.(http.Pusher); ok {
+			t.Errorf("ResponseWriter implements http.Pusher, the underlying one does not")
+		}
+		if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+			t.Errorf("Hijack: %v", err)
+		}
+		return Internal("after hijack")
+	}}.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !rec.hijacked || rec.Body.Len() != 0 {
+		t.Errorf("got hijacked %v and body %q, want the error not written after Hijack", rec.hijacked, rec.Body)
+	}
+
+	rec2 := httptest.NewRecorder()
+	Handler{Func: func(w http.ResponseWriter, r *http.Request) error {
+		if _, ok := w.(http.Hijacker); ok {
+			t.Errorf("ResponseWriter implements http.Hijacker, the underlying one does not")
+		}
+		if _, ok := w.(http.Flusher); ok {
+			t.Errorf("ResponseWriter implements http.Flusher, the underlying one does not")
+		}
+		if n, err := io.Copy(w, strings.NewReader("body")); n != 4 || err != nil {
+			t.Errorf("io.Copy: got %d, %v", n, err)
+		}
+		return Internal("after body")
+	}}.ServeHTTP(struct{ http.ResponseWriter }{rec2}, httptest.NewRequest("GET", "/", nil))
+	if rec2.Body.String() != "body" {
+		t.Errorf("io.Copy: got body %q", rec2.Body)
+	}
+}
@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+)
+
+// HeaderSetter is implemented by errors which contribute headers to the HTTP
+// response they are written to, such as Location, Allow or Cache-Control.
+type HeaderSetter interface {
+	Headers() http.Header
+}
+
+// WithHeader annotates err with the response header key set to value.
+// If err is nil, WithHeader returns nil.
+func WithHeader(err error, key, value string) error {
+	if err == nil {
+		return nil
+	}
+	h := make(header)
+	http.Header(h).Set(key, value)
+	err = withMetadata(err, h)
+	Default().created(context.Background(), err)
+	return err
+}
+
+// header is the metadata added by WithHeader.
+type header http.Header
+
+func (h header) Headers() http.Header { return http.Header(h).Clone() }
+
+// GetHeaders returns the headers contributed by every HeaderSetter in err's
+// chain, ignoring the errors inside a boundary. When several errors set the
+// same header, the outermost one wins.
+func GetHeaders(err error) http.Header {
+	return getHeaders(err, unwrapOutside)
+}
+
+// getHeaders is like GetHeaders, walking err's chain with next.
+func getHeaders(err error, next func(error) error) http.Header {
+	var setters []HeaderSetter
+	for err != nil {
+		if hs, ok := metaOf(err).(HeaderSetter); ok {
+			setters = append(setters, hs)
+		}
+		err = next(err)
+	}
+	h := make(http.Header)
+	for i := len(setters) - 1; i >= 0; i-- {
+		for k, v := range setters[i].Headers() {
+			h[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+	return h
+}
+
+// setHeaders sets the response headers for err with the following precedence,
+// lowest first: headers derived from rate limits, challenges and incident
+// IDs, then the headers of each HeaderSetter in the chain from the innermost
+// to the outermost error. In Production mode only the errors whose message
+// is rendered contribute HeaderSetter headers, see ownLayer; rate limits and
+// challenges are protocol headers and are always looked up in the whole
+// chain.
+func (f *Factory) setHeaders(h http.Header, code int, err error, m Mode) {
+	next := unwrapOutside
+	if m == Production {
+		next = ownLayer(err)
+	}
+	setRateLimitHeaders(h, err)
+	setChallengeHeader(h, code, err)
+	if id := IncidentID(err); id != "" {
+		h.Set(f.cfg.IncidentHeader, id)
+	}
+	for k, v := range getHeaders(err, next) {
+		h[k] = v
+	}
+}
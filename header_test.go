@@ -0,0 +1,67 @@
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type allowError []string
+
+func (a allowError) Error() string { return "method not allowed" }
+
+func (a allowError) Headers() http.Header {
+	return http.Header{"Allow": a}
+}
+
+func TestGetHeaders(t *testing.T) {
+	err := WithHeader(
+		Wrap(
+			WithHeader(
+				WithHeader(NotFound("gone"), "cache-control", "no-store"),
+				"X-Reason", "inner",
+			),
+			"lookup",
+		),
+		"x-reason", "outer",
+	)
+	want := http.Header{
+		"Cache-Control": {"no-store"},
+		"X-Reason":      {"outer"},
+	}
+	if got := GetHeaders(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetHeaders: got %v, want %v", got, want)
+	}
+	if !HasType(err, TypeNotFound) || err.Error() != "lookup: gone" {
+		t.Errorf("WithHeader changed the error: %v", err)
+	}
+	if WithHeader(nil, "Allow", "GET") != nil {
+		t.Errorf("WithHeader(nil): got non-nil error")
+	}
+}
+
+func TestWriteHTTPHeaders(t *testing.T) {
+	err := WithHeader(
+		WrapType(allowError{"GET", "HEAD"}, NewCustomType("method not allowed", http.StatusMethodNotAllowed), "delete"),
+		"Cache-Control", "no-store",
+	)
+	rec := httptest.NewRecorder()
+	WriteHTTP(rec, err)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status: got %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if got := rec.Header()["Allow"]; !reflect.DeepEqual(got, []string{"GET", "HEAD"}) {
+		t.Errorf("Allow: got %v", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control: got %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	WriteHTTP(rec, WithHeader(LimitExceededAfter(time.Second, "slow down"), "Retry-After", "60"))
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After: got %q, want the HeaderSetter to take precedence", got)
+	}
+}
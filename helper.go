@@ -1,178 +1,261 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
-	"golang.org/x/text/language"
 )
 
-var (
-	localizer = i18n.NewLocalizer(i18n.NewBundle(language.English), "en")
-)
+// SetBundle sets the message bundle used to localize errors created by NewI18n,
+// and to localize error responses in the language of the request.
+func SetBundle(b *i18n.Bundle) {
+	updateDefault(func(cfg *Config) { cfg.Bundle = b })
+}
 
-func newErr(e error, message string, eType Typer) error {
-	if e == nil {
-		return &fundamental{
-			msg:   message,
-			eType: eType,
-			stack: callers(),
+// localize returns the message of err's localize config in the languages
+// langs, which may be given in Accept-Language format. The message is looked
+// up in the bundle of the Factory which created err.
+func localize(err error, langs ...string) (string, bool) {
+	lc := localizeConfigOf(err)
+	if lc == nil {
+		return "", false
+	}
+	b := Default().cfg.Bundle
+	for e := err; e != nil; e = unwrapOutside(e) {
+		if l, ok := e.(*localization); ok && l.bundle != nil {
+			b = l.bundle
+			break
 		}
 	}
-	return &withMessage{
-		cause: e,
-		msg:   message,
-		eType: eType,
+	msg, e := i18n.NewLocalizer(b, langs...).Localize(lc)
+	if e != nil {
+		return "", false
 	}
+	return msg, true
 }
 
-func newErrf(e error, eType Typer, format string, args ...interface{}) error {
-	message := fmt.Sprintf(format, args...)
-	return newErr(e, message, eType)
+// getErrType returns the type of the first error in err's chain outside any
+// boundary which has one, or the type given by the classifiers or the
+// default type of the default Factory if none has. It is the type used to
+// classify err everywhere: by GetAPIError, HTTPWriter, Collector, GetSeverity,
+// RecordOnSpan and NewSentryEvent.
+func getErrType(err error) Typer {
+	return Default().typeOf(err)
 }
 
-func getErrType(err error) Typer {
-	e, _ := err.(interface {
-		Type() Typer
-	})
-	if e == nil {
-		return defaultErrType
+// hasTyper reports whether an error in err's chain has a type.
+func hasTyper(err error) bool {
+	for err != nil {
+		if _, ok := err.(interface {
+			Type() Typer
+		}); ok {
+			return true
+		}
+		err = Unwrap(err)
 	}
-	return e.Type()
+	return false
 }
 
 // Internal helper method for creating internal errors
 func Internal(message string) error {
-	return newErr(nil, message, TypeInternal)
+	return Default().newErr(context.Background(), message, template{}, TypeInternal)
 }
 
 // Internalf helper method for creating internal errors with formatted message
 func Internalf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeInternal, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeInternal)
 }
 
 // Validation is a helper function to create a new error of type TypeValidation
 func Validation(message string) error {
-	return newErr(nil, message, TypeValidation)
+	return Default().newErr(context.Background(), message, template{}, TypeValidation)
 }
 
 // Validationf is a helper function to create a new error of type TypeValidation, with formatted message
 func Validationf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeValidation, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeValidation)
 }
 
 // Input is a helper function to create a new error of type TypeInput
 func Input(message string) error {
-	return newErr(nil, message, TypeInput)
+	return Default().newErr(context.Background(), message, template{}, TypeInput)
 }
 
 // Inputf is a helper function to create a new error of type TypeInput, with formatted message
 func Inputf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeInput, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeInput)
 }
 
 // Duplicate is a helper function to create a new error of type TypeDuplicate
 func Duplicate(message string) error {
-	return newErr(nil, message, TypeDuplicate)
+	return Default().newErr(context.Background(), message, template{}, TypeDuplicate)
 }
 
 // Duplicatef is a helper function to create a new error of type TypeDuplicate, with formatted message
 func Duplicatef(format string, args ...interface{}) error {
-	return newErrf(nil, TypeDuplicate, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeDuplicate)
 }
 
 // Unauthenticated is a helper function to create a new error of type TypeUnauthenticated
 func Unauthenticated(message string) error {
-	return newErr(nil, message, TypeUnauthenticated)
+	return Default().newErr(context.Background(), message, template{}, TypeUnauthenticated)
 }
 
 // Unauthenticatedf is a helper function to create a new error of type TypeUnauthenticated, with formatted message
 func Unauthenticatedf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeUnauthenticated, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeUnauthenticated)
 
 }
 
 // NoPermission is a helper function to create a new error of type TypeNoPermission
 func NoPermission(message string) error {
-	return newErr(nil, message, TypeNoPermission)
+	return Default().newErr(context.Background(), message, template{}, TypeNoPermission)
 }
 
 // NoPermissionf is a helper function to create a new error of type TypeNoPermission, with formatted message
 func NoPermissionf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeNoPermission, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeNoPermission)
 }
 
 // Empty is a helper function to create a new error of type TypeEmpty
 func Empty(message string) error {
-	return newErr(nil, message, TypeEmpty)
+	return Default().newErr(context.Background(), message, template{}, TypeEmpty)
 }
 
 // Emptyf is a helper function to create a new error of type TypeEmpty, with formatted message
 func Emptyf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeEmpty, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeEmpty)
 }
 
 // NotFound is a helper function to create a new error of type TypeNotFound
 func NotFound(message string) error {
-	return newErr(nil, message, TypeNotFound)
+	return Default().newErr(context.Background(), message, template{}, TypeNotFound)
 }
 
 // NotFoundf is a helper function to create a new error of type TypeNotFound, with formatted message
 func NotFoundf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeNotFound, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeNotFound)
 }
 
 // LimitExceeded is a helper function to create a new error of type TypeLimitExceeded
 func LimitExceeded(message string) error {
-	return newErr(nil, message, TypeLimitExceeded)
+	return Default().newErr(context.Background(), message, template{}, TypeLimitExceeded)
 }
 
 // LimitExceededf is a helper function to create a new error of type TypeLimitExceeded, with formatted message
 func LimitExceededf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeLimitExceeded, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeLimitExceeded)
 }
 
 // SubscriptionExpired is a helper function to create a new error of type TypeSubscriptionExpired
 func SubscriptionExpired(message string) error {
-	return newErr(nil, message, TypeSubscriptionExpired)
+	return Default().newErr(context.Background(), message, template{}, TypeSubscriptionExpired)
 }
 
 // SubscriptionExpiredf is a helper function to create a new error of type TypeSubscriptionExpired, with formatted message
 func SubscriptionExpiredf(format string, args ...interface{}) error {
-	return newErrf(nil, TypeSubscriptionExpired, format, args...)
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeSubscriptionExpired)
 }
 
-// HasType will check if the provided err type is available anywhere nested in the error
-func HasType(err error, et Typer) bool {
+// Unavailable is a helper function to create a new error of type TypeUnavailable
+func Unavailable(message string) error {
+	return Default().newErr(context.Background(), message, template{}, TypeUnavailable)
+}
+
+// Unavailablef is a helper function to create a new error of type TypeUnavailable, with formatted message
+func Unavailablef(format string, args ...interface{}) error {
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeUnavailable)
+}
+
+// Timeout is a helper function to create a new error of type TypeTimeout
+func Timeout(message string) error {
+	return Default().newErr(context.Background(), message, template{}, TypeTimeout)
+}
+
+// Timeoutf is a helper function to create a new error of type TypeTimeout, with formatted message
+func Timeoutf(format string, args ...interface{}) error {
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeTimeout)
+}
+
+// Canceled is a helper function to create a new error of type TypeCanceled
+func Canceled(message string) error {
+	return Default().newErr(context.Background(), message, template{}, TypeCanceled)
+}
+
+// Canceledf is a helper function to create a new error of type TypeCanceled, with formatted message
+func Canceledf(format string, args ...interface{}) error {
+	return Default().newErr(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeCanceled)
+}
+
+// TypeOption changes how HasType searches the error chain.
+type TypeOption int
+
+const (
+	// StopAtBoundary makes HasType ignore the errors inside a boundary
+	// created by Opaque or Boundary.
+	StopAtBoundary TypeOption = iota + 1
+)
+
+// HasType will check if the provided err type is available anywhere nested in the error.
+// With StopAtBoundary, errors inside a boundary created by Opaque or Boundary are ignored.
+func HasType(err error, et Typer, opts ...TypeOption) bool {
 	if err == nil {
 		return false
 	}
 	e, _ := err.(interface {
 		Type() Typer
 	})
-	if e == nil {
-		return HasType(errors.Unwrap(err), et)
-	}
-	if e.Type() == et {
+	if e != nil && e.Type() == et {
 		return true
 	}
-	return HasType(errors.Unwrap(err), et)
+	for _, opt := range opts {
+		if opt == StopAtBoundary && isBoundary(err) {
+			return false
+		}
+	}
+	return HasType(errors.Unwrap(err), et, opts...)
 }
 
 // GetAPIError tries to get the code and message from any error.
+// The message is scrubbed by the scrubbers set by SetScrubbers. The code is
+// the HTTP status of err's type, that of the first error in err's chain
+// outside any boundary which has one, even if it is wrapped by errors of
+// other packages. An error without a type is given one by the Classifiers
+// of the default Factory, or its DefaultType. Errors of other packages
+// implementing APIError without a type are honored if no error before them
+// has a type.
 func GetAPIError(err error) (code int, msg string) {
 	if err == nil {
-		return defaultErrType.HTTPStatusCode(), DefaultMessage
+		return Default().cfg.DefaultType.HTTPStatusCode(), DefaultMessage
 	}
-	msg = err.Error()
-	for err != nil {
-		if apiErr, _ := err.(APIError); apiErr != nil {
-			code, _ = apiErr.APIError()
-			return code, msg
+	msg = Scrub(err.Error())
+	if apiErr := foreignAPIError(err); apiErr != nil {
+		code, _ = apiErr.APIError()
+		return code, msg
+	}
+	return getErrType(err).HTTPStatusCode(), msg
+}
+
+// foreignAPIError returns the first error in err's chain, outside any
+// boundary, which implements APIError but has no type, if no error before
+// it has a type. GetAPIError takes the status from it instead of err's type.
+func foreignAPIError(err error) APIError {
+	for e := err; e != nil; e = unwrapOutside(e) {
+		if _, ok := e.(*withStack); ok {
+			// withStack's APIError defers to the error it wraps.
+			continue
+		}
+		if _, ok := e.(interface {
+			Type() Typer
+		}); ok {
+			return nil
+		}
+		if apiErr, ok := e.(APIError); ok {
+			return apiErr
 		}
-		err = Unwrap(err)
 	}
-	return defaultErrType.HTTPStatusCode(), msg
+	return nil
 }
 
 // GetLocalizeConfig tries to get the localize config from error, may be nil.
@@ -185,3 +268,15 @@ func GetLocalizeConfig(err error) (lc *i18n.LocalizeConfig) {
 	}
 	return nil
 }
+
+// localizeConfigOf returns the localize config of the first error in err's
+// chain which has one, ignoring the errors inside a boundary, may be nil.
+func localizeConfigOf(err error) *i18n.LocalizeConfig {
+	for err != nil {
+		if lc := GetLocalizeConfig(err); lc != nil {
+			return lc
+		}
+		err = unwrapOutside(err)
+	}
+	return nil
+}
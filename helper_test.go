@@ -105,6 +105,47 @@ func TestHasType(t *testing.T) {
 	}
 }
 
+func TestGetErrType(t *testing.T) {
+	if got := getErrType(fmt.Errorf("lookup: %w", NotFound("missing"))); got != TypeNotFound {
+		t.Errorf("getErrType of a foreign wrapper: got %v, want %v", got, TypeNotFound)
+	}
+	if got := getErrType(fmt.Errorf("lookup: %w", Opaque(NotFound("missing"), TypeUnavailable))); got != TypeUnavailable {
+		t.Errorf("getErrType of a foreign wrapper of a boundary: got %v, want %v", got, TypeUnavailable)
+	}
+	for name, err := range wrappers(NotFound("missing")) {
+		if name == "boundary" || name == "ClientError" {
+			continue
+		}
+		if got := getErrType(err); got != TypeNotFound {
+			t.Errorf("%s: getErrType: got %v, want %v", name, got, TypeNotFound)
+		}
+	}
+}
+
+func TestForeignWrapperClassification(t *testing.T) {
+	err := fmt.Errorf("load: %w", NotFound("user 42"))
+
+	if code, _ := GetAPIError(err); code != http.StatusNotFound {
+		t.Errorf("GetAPIError: got %d, want %d", code, http.StatusNotFound)
+	}
+	if got := Default().newResponse(nil, http.StatusNotFound, err, Standard); got.Type != "not_found" {
+		t.Errorf("newResponse: got type %q, want not_found", got.Type)
+	}
+	var c Collector
+	c.Observe(err)
+	if got := c.Snapshot(); len(got) != 1 || got[0].Type != "not_found" || got[0].Status != http.StatusNotFound {
+		t.Errorf("Collector: got %+v", got)
+	}
+	if got := GetSeverity(err); got != SeverityInfo {
+		t.Errorf("GetSeverity: got %v, want %v", got, SeverityInfo)
+	}
+	span := new(fakeSpan)
+	RecordOnSpan(span, err)
+	if got := span.events[0].attrs["exception.type"]; got != "not_found" {
+		t.Errorf("RecordOnSpan: got exception.type %q, want not_found", got)
+	}
+}
+
 func TestGetAPIError(t *testing.T) {
 	type args struct {
 		err error
@@ -144,7 +185,7 @@ func TestGetAPIError(t *testing.T) {
 			args: args{
 				err: fmt.Errorf("unknown error %w", fmt.Errorf("hello world")),
 			},
-			wantCode: defaultErrType.HTTPStatusCode(),
+			wantCode: Default().Config().DefaultType.HTTPStatusCode(),
 			wantMsg:  "unknown error hello world",
 		},
 		{
@@ -277,6 +318,30 @@ func TestAllGetAPIError(t *testing.T) {
 			want:  http.StatusPaymentRequired,
 			want2: "your subscription has expired",
 		},
+		{
+			name: "TypeUnavailable",
+			args: args{
+				err: Unavailable("database unavailable"),
+			},
+			want:  http.StatusServiceUnavailable,
+			want2: "database unavailable",
+		},
+		{
+			name: "TypeTimeout",
+			args: args{
+				err: Timeoutf("query took longer than %s", "5s"),
+			},
+			want:  http.StatusGatewayTimeout,
+			want2: "query took longer than 5s",
+		},
+		{
+			name: "TypeCanceled",
+			args: args{
+				err: Canceled("client closed the request"),
+			},
+			want:  StatusClientClosedRequest,
+			want2: "client closed the request",
+		},
 		{
 			name: "Custom Type",
 			args: args{
@@ -334,3 +399,14 @@ func TestGetLocalizeConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLocalizeConfigOuterOnly(t *testing.T) {
+	lc := &i18n.LocalizeConfig{MessageID: "user_not_found"}
+	err := Wrap(NewI18n(TypeNotFound, lc), "load")
+	if got := GetLocalizeConfig(err); got != nil {
+		t.Errorf("GetLocalizeConfig: got %v, want nil for a wrapped error", got)
+	}
+	if got := localizeConfigOf(err); got != lc {
+		t.Errorf("localizeConfigOf: got %v, want %v", got, lc)
+	}
+}
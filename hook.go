@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Hook is called with every error created or wrapped by the package, e.g. to
+// count errors by type or record them on the active trace: the errors of New,
+// Errorf, NewI18n and the type helpers such as NotFound, of the Wrap and
+// WithMessage families, of annotations such as WithSeverity or WithHeader,
+// and of Boundary, Opaque, FromHTTP, FromContext and Retry. The context is
+// the one passed to the constructor, that of the request for FromHTTP, or
+// context.Background().
+//
+// Errors created while a hook runs on the same goroutine, such as by the hook
+// itself, do not invoke the hooks again.
+type Hook func(ctx context.Context, err error)
+
+type hook struct {
+	fn   Hook
+	rate float64
+}
+
+var (
+	hooksMu sync.Mutex
+	hooks   atomic.Value // []*hook, replaced on every change
+)
+
+// OnCreate registers fn to be called for every created error, and returns a
+// function removing it. Hooks run synchronously on the goroutine creating
+// the error, in the order they were registered.
+//
+// Unlike the Hooks of a Config, the hooks registered with OnCreate are not
+// part of any Factory: they are process-wide instrumentation, such as
+// metrics or tracing, invoked for the errors of every Factory.
+func OnCreate(fn Hook) (remove func()) {
+	return addHook(&hook{fn: fn, rate: 1})
+}
+
+// OnCreateSampled is like OnCreate, but fn is only called for a random sample
+// of the created errors, of the given rate between 0 and 1.
+func OnCreateSampled(rate float64, fn Hook) (remove func()) {
+	return addHook(&hook{fn: fn, rate: rate})
+}
+
+func addHook(h *hook) func() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	old, _ := hooks.Load().([]*hook)
+	hooks.Store(append(old[:len(old):len(old)], h))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { removeHook(h) })
+	}
+}
+
+func removeHook(h *hook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	old, _ := hooks.Load().([]*hook)
+	hs := make([]*hook, 0, len(old))
+	for _, o := range old {
+		if o != h {
+			hs = append(hs, o)
+		}
+	}
+	hooks.Store(hs)
+}
+
+// created invokes the registered hooks for err.
+func created(ctx context.Context, err error) {
+	runHooks(ctx, err, nil)
+}
+
+// runHooks invokes the registered hooks, then the hooks own, for err. It
+// does not allocate when there are no hooks.
+func runHooks(ctx context.Context, err error, own []*hook) {
+	hs, _ := hooks.Load().([]*hook)
+	if len(hs) == 0 && len(own) == 0 {
+		return
+	}
+	if inHook() {
+		return
+	}
+	for _, list := range [2][]*hook{hs, own} {
+		for _, h := range list {
+			if h.rate < 1 && rand.Float64() >= h.rate {
+				continue
+			}
+			callHook(h, ctx, err)
+		}
+	}
+}
+
+// callHook calls the hook h. It is never inlined, so that the return address
+// of the call, hookReturnPC, marks the goroutines running a hook.
+//
+//go:noinline
+func callHook(h *hook, ctx context.Context, err error) {
+	h.fn(ctx, err)
+}
+
+// hookReturnPC is the return address of the call of the hook in callHook.
+var hookReturnPC uintptr
+
+func init() {
+	callHook(&hook{fn: func(context.Context, error) {
+		var pc [1]uintptr
+		runtime.Callers(2, pc[:])
+		hookReturnPC = pc[0]
+	}}, context.Background(), nil)
+}
+
+// hookScanFrames is the number of frames inHook reads from the stack at once.
+const hookScanFrames = 32
+
+// inHook reports whether the calling goroutine is running a hook, i.e.
+// whether callHook is on its stack. Reading the return addresses of the
+// stack is cheaper than keeping track of the goroutines running hooks,
+// which are not identifiable without parsing the output of runtime.Stack.
+func inHook() bool {
+	var pcs [hookScanFrames]uintptr
+	for skip := 3; ; skip += hookScanFrames {
+		n := runtime.Callers(skip, pcs[:])
+		for _, pc := range pcs[:n] {
+			if pc == hookReturnPC {
+				return true
+			}
+		}
+		if n < len(pcs) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package errors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnCreate(t *testing.T) {
+	var got []error
+	remove := OnCreate(func(ctx context.Context, err error) {
+		if ctx == nil {
+			t.Errorf("hook called with nil context")
+		}
+		got = append(got, err)
+	})
+
+	errs := []error{
+		New("new"),
+		Errorf("errorf %d", 1),
+		NotFound("not found"),
+		Inputf("input %d", 2),
+		Wrap(io.EOF, "wrap"),
+		Wrapf(io.EOF, "wrapf %d", 3),
+		WrapType(io.EOF, TypeEmpty, "wrap type"),
+		WithStack(io.EOF),
+		WithMessage(io.EOF, "with message"),
+	}
+	remove()
+	remove()
+	New("after remove")
+
+	if len(got) != len(errs) {
+		t.Fatalf("OnCreate: hook called %d times, want %d", len(got), len(errs))
+	}
+	for i := range errs {
+		if got[i] != errs[i] {
+			t.Errorf("OnCreate: call %d got %v, want %v", i, got[i], errs[i])
+		}
+	}
+}
+
+func TestOnCreateRecursion(t *testing.T) {
+	calls := 0
+	defer OnCreate(func(ctx context.Context, err error) {
+		calls++
+		Wrap(err, "created by hook")
+	})()
+	New("x")
+	if calls != 1 {
+		t.Errorf("OnCreate: hook called %d times, want 1", calls)
+	}
+}
+
+func TestOnCreateWrappers(t *testing.T) {
+	var got []error
+	remove := OnCreate(func(ctx context.Context, err error) { got = append(got, err) })
+	defer remove()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	errs := []error{
+		WithSeverity(io.EOF, SeverityWarn),
+		WithHeader(io.EOF, "X-Reason", "eof"),
+		WithChallenge(io.EOF, Challenge{Scheme: "Bearer"}),
+		WithOrigin(io.EOF),
+		WithIncidentID(io.EOF, "inc-1"),
+		Boundary(io.EOF),
+		Opaque(io.EOF, TypeUnavailable),
+		FromHTTP(req, &http.Response{StatusCode: http.StatusNotFound}, nil),
+		FromHTTP(req, nil, io.EOF),
+		Retry(context.Background(), RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Nanosecond}, func(context.Context) error { return retryAfterError(0) }),
+		LimitExceededRate(RateLimit{RetryAfter: time.Second}, "slow down"),
+		UnauthenticatedChallenge(Challenge{Scheme: "Bearer"}, "token expired"),
+	}
+	if len(got) != len(errs) {
+		t.Fatalf("OnCreate: hook called %d times, want %d", len(got), len(errs))
+	}
+	for i := range errs {
+		if got[i] != errs[i] {
+			t.Errorf("OnCreate: call %d got %v, want %v", i, got[i], errs[i])
+		}
+	}
+}
+
+func TestOnCreateRecursionOtherGoroutine(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	defer OnCreate(func(ctx context.Context, err error) {
+		mu.Lock()
+		got = append(got, err.Error())
+		mu.Unlock()
+		if err.Error() == "outer" {
+			done := make(chan struct{})
+			go func() {
+				New("other goroutine")
+				close(done)
+			}()
+			<-done
+		}
+	})()
+	New("outer")
+	if len(got) != 2 || got[1] != "other goroutine" {
+		t.Errorf("OnCreate: got calls for %q, want the error of the other goroutine hooked", got)
+	}
+}
+
+func TestOnCreateRecursionDeep(t *testing.T) {
+	calls := 0
+	var deep func(n int)
+	deep = func(n int) {
+		if n == 0 {
+			New("deep")
+			return
+		}
+		deep(n - 1)
+	}
+	defer OnCreate(func(ctx context.Context, err error) {
+		calls++
+		deep(100)
+	})()
+	New("x")
+	if calls != 1 {
+		t.Errorf("OnCreate: hook called %d times, want 1", calls)
+	}
+}
+
+func TestOnCreateSampled(t *testing.T) {
+	var never, always int
+	defer OnCreateSampled(0, func(context.Context, error) { never++ })()
+	defer OnCreateSampled(1, func(context.Context, error) { always++ })()
+	for i := 0; i < 100; i++ {
+		New("x")
+	}
+	if never != 0 || always != 100 {
+		t.Errorf("OnCreateSampled: got %d and %d calls, want 0 and 100", never, always)
+	}
+}
+
+func TestOnCreateConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			remove := OnCreate(func(context.Context, error) {})
+			New("x")
+			remove()
+		}()
+	}
+	wg.Wait()
+	if hs, _ := hooks.Load().([]*hook); len(hs) != 0 {
+		t.Errorf("OnCreate: %d hooks left after removal", len(hs))
+	}
+}
+
+func TestCreatedNoHooksAllocs(t *testing.T) {
+	err := New("x")
+	ctx := context.Background()
+	if n := testing.AllocsPerRun(100, func() { created(ctx, err) }); n != 0 {
+		t.Errorf("created: got %v allocations without hooks, want 0", n)
+	}
+}
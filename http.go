@@ -0,0 +1,29 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+)
+
+// WriteHTTP writes err as an HTTP response. The status code and message
+// are taken from GetAPIError, the message depending on the mode set by
+// SetMode, and the headers carried by the error, such as
+// Retry-After and RateLimit-* for rate limited requests, WWW-Authenticate
+// for unauthenticated and forbidden ones and those of any HeaderSetter in the
+// chain, are set.
+//
+// Errors with a 5xx status get an incident ID from AttachIncidentID, which
+// is rendered in the body and the IncidentHeader of the Config.
+func WriteHTTP(w http.ResponseWriter, err error) {
+	Default().WriteHTTP(w, err)
+}
+
+// WriteHTTP is like the WriteHTTP function, using the Mode, IncidentHeader
+// and RequestIDHeaders of f.
+func (f *Factory) WriteHTTP(w http.ResponseWriter, err error) {
+	m := f.cfg.Mode
+	err = attachIncidentID(context.Background(), err, m)
+	code, _ := GetAPIError(err)
+	f.setHeaders(w.Header(), code, err, m)
+	writeResponse(w, f.newResponse(nil, code, err, m), MediaTypeText)
+}
@@ -0,0 +1,19 @@
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTTP(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteHTTP(rec, Wrap(NotFound("user not found"), "load profile"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status: got %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != "load profile: user not found" {
+		t.Errorf("body: got %q", got)
+	}
+}
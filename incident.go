@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+type incidentKey struct{}
+
+// ContextWithIncidentID returns a copy of ctx carrying the incident ID id, to
+// be used instead of a generated one by AttachIncidentID.
+func ContextWithIncidentID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, incidentKey{}, id)
+}
+
+// IncidentIDFromContext returns the incident ID carried by ctx, if any.
+func IncidentIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(incidentKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithIncidentID annotates err with the incident ID id, which is printed by
+// %+v and rendered in error responses. If err is nil, WithIncidentID
+// returns nil.
+func WithIncidentID(err error, id string) error {
+	return withIncidentID(context.Background(), err, id)
+}
+
+func withIncidentID(ctx context.Context, err error, id string) error {
+	if err == nil {
+		return nil
+	}
+	err = withMetadata(err, incidentID(id))
+	Default().created(ctx, err)
+	return err
+}
+
+// incidentID is the metadata added by WithIncidentID.
+type incidentID string
+
+func (id incidentID) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		io.WriteString(s, "\nincident id: "+string(id))
+	}
+}
+
+// IncidentID returns the outermost incident ID attached to err's chain, or
+// an empty string. Incident IDs inside a boundary are ignored.
+func IncidentID(err error) string {
+	for err != nil {
+		if id, ok := metaOf(err).(incidentID); ok {
+			return string(id)
+		}
+		err = unwrapOutside(err)
+	}
+	return ""
+}
+
+// AttachIncidentID returns err annotated with an incident ID if its HTTP
+// status, as returned by GetAPIError, is 5xx and it carries none yet. The ID
+// is taken from ctx, see ContextWithIncidentID, or generated.
+//
+// Handler calls AttachIncidentID before logging and writing an error, so
+// that the ID in the response matches the logged one. Call it before doing
+// both when using HTTPWriter directly.
+func AttachIncidentID(ctx context.Context, err error) error {
+	if err == nil || IncidentID(err) != "" {
+		return err
+	}
+	if code, _ := GetAPIError(err); code < 500 {
+		return err
+	}
+	return newIncidentID(ctx, err)
+}
+
+// attachIncidentID is like AttachIncidentID, but also annotates the errors
+// whose message is redacted in mode m, so that the correlation ID rendered
+// instead is recorded with the error.
+func attachIncidentID(ctx context.Context, err error, m Mode) error {
+	if err == nil || IncidentID(err) != "" || !redacted(err, m) {
+		return AttachIncidentID(ctx, err)
+	}
+	return newIncidentID(ctx, err)
+}
+
+// newIncidentID annotates err with the incident ID carried by ctx, or a
+// generated one.
+func newIncidentID(ctx context.Context, err error) error {
+	id, ok := IncidentIDFromContext(ctx)
+	if !ok {
+		id = newEventID()
+	}
+	return withIncidentID(ctx, err, id)
+}
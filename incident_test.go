@@ -0,0 +1,143 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAttachIncidentID(t *testing.T) {
+	if err := AttachIncidentID(context.Background(), NotFound("x")); IncidentID(err) != "" {
+		t.Errorf("4xx: got incident ID %q", IncidentID(err))
+	}
+	if err := AttachIncidentID(context.Background(), nil); err != nil {
+		t.Errorf("nil: got %v", err)
+	}
+
+	err := AttachIncidentID(context.Background(), Wrap(io.EOF, "read"))
+	id := IncidentID(err)
+	if !regexp.MustCompile("^[0-9a-f]{32}$").MatchString(id) {
+		t.Errorf("5xx: got incident ID %q", id)
+	}
+	if again := AttachIncidentID(context.Background(), err); IncidentID(again) != id || again != err {
+		t.Errorf("attached twice: got %q", IncidentID(again))
+	}
+
+	ctx := ContextWithIncidentID(context.Background(), "inc-1")
+	if err := AttachIncidentID(ctx, Unavailable("x")); IncidentID(err) != "inc-1" {
+		t.Errorf("from context: got %q", IncidentID(err))
+	}
+}
+
+func TestIncidentIDFormat(t *testing.T) {
+	err := WithIncidentID(Internal("disk full"), "inc-2")
+	if got := err.Error(); got != "disk full" {
+		t.Errorf("Error: got %q", got)
+	}
+	if got := fmt.Sprintf("%+v", err); !strings.HasSuffix(got, "\nincident id: inc-2") {
+		t.Errorf("%%+v: got %q", got)
+	}
+	b, e := json.Marshal(err)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !strings.Contains(string(b), `"incident_id":"inc-2"`) {
+		t.Errorf("json.Marshal: got %s", b)
+	}
+	if ev := NewSentryEvent(err); ev.Tags["incident_id"] != "inc-2" {
+		t.Errorf("NewSentryEvent: got tags %v", ev.Tags)
+	}
+	if WithIncidentID(nil, "x") != nil {
+		t.Errorf("WithIncidentID(nil): got non-nil")
+	}
+}
+
+func TestHandlerIncidentID(t *testing.T) {
+	var logged string
+	h := Handler{
+		Func: func(w http.ResponseWriter, r *http.Request) error {
+			return Wrap(io.ErrUnexpectedEOF, "query users")
+		},
+		Log: func(r *http.Request, err error, detail string) { logged = detail },
+	}
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(ContextWithIncidentID(r.Context(), "inc-3"))
+	h.ServeHTTP(rec, r)
+
+	if got := rec.Header().Get("X-Incident-Id"); got != "inc-3" {
+		t.Errorf("X-Incident-Id: got %q", got)
+	}
+	var body struct {
+		IncidentID string `json:"incident_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.IncidentID != "inc-3" {
+		t.Errorf("body: got %s", rec.Body)
+	}
+	if !strings.Contains(logged, "incident id: inc-3") {
+		t.Errorf("log: got %q", logged)
+	}
+}
+
+func TestWriteHTTPIncidentID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteHTTP(rec, Internal("disk full"))
+	id := rec.Header().Get("X-Incident-Id")
+	if id == "" || rec.Body.String() != "disk full\nincident id: "+id+"\n" {
+		t.Errorf("WriteHTTP: got %q with incident ID %q", rec.Body, id)
+	}
+
+	rec = httptest.NewRecorder()
+	WriteHTTP(rec, NotFound("x"))
+	if got := rec.Header().Get("X-Incident-Id"); got != "" {
+		t.Errorf("WriteHTTP 4xx: got incident ID %q", got)
+	}
+}
+
+func TestProductionIncidentID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", MediaTypeProblem)
+	HTTPWriter{Mode: Production}.Write(rec, r, Internal("disk full"))
+	var body struct {
+		CorrelationID string `json:"correlation_id"`
+		IncidentID    string `json:"incident_id"`
+		Detail        string `json:"detail"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.IncidentID == "" || body.CorrelationID != body.IncidentID || body.Detail != DefaultMessage {
+		t.Errorf("body: got %s", rec.Body)
+	}
+}
+
+func TestProductionCorrelationIDLogged(t *testing.T) {
+	quota := NewCustomType("quota", http.StatusTooManyRequests)
+	quota.Internal = true
+	var logged error
+	h := Handler{
+		Func: func(w http.ResponseWriter, r *http.Request) error {
+			return WrapType(io.EOF, quota, "tenant 42 over quota")
+		},
+		Log:    func(r *http.Request, err error, detail string) { logged = err },
+		Writer: HTTPWriter{Mode: Production},
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var body struct {
+		CorrelationID string `json:"correlation_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.CorrelationID == "" || IncidentID(logged) != body.CorrelationID {
+		t.Errorf("correlation ID %q, logged incident ID %q", body.CorrelationID, IncidentID(logged))
+	}
+}
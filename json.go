@@ -0,0 +1,60 @@
+package errors
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// jsonError is the JSON representation of the errors created by this package.
+type jsonError struct {
+	Message   string     `json:"message"`
+	Time      *time.Time `json:"time,omitempty"`
+	Goroutine uint64     `json:"goroutine,omitempty"`
+	Stack     StackTrace `json:"stack,omitempty"`
+
+	Fields     map[string]interface{} `json:"fields,omitempty"`
+	IncidentID string                 `json:"incident_id,omitempty"`
+}
+
+// marshalJSON encodes err's message together with the innermost origin and
+// stack trace recorded in its chain, and the fields and incident ID attached
+// to it. The message and fields are scrubbed.
+func marshalJSON(err error) ([]byte, error) {
+	je := jsonError{
+		Message:    Scrub(err.Error()),
+		Stack:      stackOf(err),
+		IncidentID: IncidentID(err),
+	}
+	if o := originOf(err); o != nil {
+		je.Time = &o.time
+		je.Goroutine = o.goroutine
+	}
+	if fields := Fields(err); len(fields) > 0 {
+		je.Fields = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			je.Fields[f.Key] = scrubValue(f.Value)
+		}
+	}
+	return json.Marshal(je)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f *fundamental) MarshalJSON() ([]byte, error) { return marshalJSON(f) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withStack) MarshalJSON() ([]byte, error) { return marshalJSON(w) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withMessage) MarshalJSON() ([]byte, error) { return marshalJSON(w) }
+
+// MarshalJSON implements json.Marshaler.
+func (l *localization) MarshalJSON() ([]byte, error) { return marshalJSON(l) }
+
+// MarshalJSON implements json.Marshaler.
+func (w *withMeta) MarshalJSON() ([]byte, error) { return marshalJSON(w) }
+
+// MarshalJSON implements json.Marshaler.
+func (b *boundary) MarshalJSON() ([]byte, error) { return marshalJSON(b) }
+
+// MarshalJSON implements json.Marshaler.
+func (r *retryError) MarshalJSON() ([]byte, error) { return marshalJSON(r) }
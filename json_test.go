@@ -2,8 +2,12 @@ package errors
 
 import (
 	"encoding/json"
+	"io"
+	"net/http"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFrameMarshalText(t *testing.T) {
@@ -49,3 +53,70 @@ func TestFrameMarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorMarshalJSON(t *testing.T) {
+	var got struct {
+		Message   string
+		Time      *time.Time
+		Goroutine uint64
+		Stack     []string
+	}
+	b, err := json.Marshal(Wrap(New("inner"), "outer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Message != "outer: inner" || got.Time != nil || got.Goroutine != 0 || len(got.Stack) == 0 {
+		t.Errorf("json.Marshal: got %s", b)
+	}
+
+	b, err = json.Marshal(WithOrigin(New("inner")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Time == nil || got.Goroutine == 0 {
+		t.Errorf("json.Marshal: origin missing in %s", b)
+	}
+}
+
+func TestWrapperMarshalJSON(t *testing.T) {
+	for name, err := range wrappers(New("inner")) {
+		if name == "ClientError" {
+			continue
+		}
+		var got struct {
+			Message string
+			Stack   []string
+		}
+		b, jerr := json.Marshal(err)
+		if jerr != nil {
+			t.Fatalf("%s: %v", name, jerr)
+		}
+		if jerr := json.Unmarshal(b, &got); jerr != nil {
+			t.Fatalf("%s: %v", name, jerr)
+		}
+		if got.Message != err.Error() || len(got.Stack) == 0 {
+			t.Errorf("%s: json.Marshal: got %s", name, b)
+		}
+	}
+}
+
+func TestClientErrorMarshalJSON(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://example.com/users/42", nil)
+	b, err := json.Marshal(FromHTTP(req, &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("gone"))}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ClientError
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != "GET" || got.URL != "http://example.com/users/42" || got.StatusCode != http.StatusNotFound || got.Body != "gone" {
+		t.Errorf("json.Marshal: got %s", b)
+	}
+}
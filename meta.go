@@ -0,0 +1,56 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// withMeta annotates an error with metadata which changes neither its
+// message nor its type, such as an incident ID, a rate limit or response
+// headers. The metadata implements the methods looked up by the getters,
+// such as RetryAfter or Severity, and is printed by %+v if it implements
+// fmt.Formatter.
+type withMeta struct {
+	error
+	meta interface{}
+}
+
+// withMetadata returns err annotated with meta.
+func withMetadata(err error, meta interface{}) error {
+	return &withMeta{err, meta}
+}
+
+func (w *withMeta) Cause() error { return w.error }
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (w *withMeta) Unwrap() error { return w.error }
+
+func (w *withMeta) messageTemplate() (string, []interface{}) { return templateOf(w.error) }
+
+func (w *withMeta) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", w.Cause())
+			if f, ok := w.meta.(fmt.Formatter); ok {
+				f.Format(s, verb)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// metaOf returns the metadata of err if it was annotated by withMetadata,
+// or err itself, so that the getters find both the metadata of this package
+// and the methods implemented by other errors.
+func metaOf(err error) interface{} {
+	if w, ok := err.(*withMeta); ok {
+		return w.meta
+	}
+	return err
+}
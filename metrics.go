@@ -0,0 +1,156 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Collector counts errors by type name, HTTP status and, optionally,
+// fingerprint. Errors are counted by Observe, by the Hook registered with
+// OnCreate, or by an HTTPWriter with the collector as Metrics.
+//
+// The counters are updated without locking. The zero value is ready to use,
+// a Collector must not be copied after first use.
+type Collector struct {
+	// Name is the name of the metric exposed by ServeHTTP, errors_total if
+	// empty.
+	Name string
+	// Fingerprint enables counting by the Fingerprint of the errors too.
+	// Note that this makes the number of counters grow with the number of
+	// distinct errors.
+	Fingerprint bool
+
+	counters sync.Map // metricKey -> *uint64
+}
+
+type metricKey struct {
+	typ         string
+	status      int
+	fingerprint string
+}
+
+// ErrorCount is the number of errors observed by a Collector with the same
+// type, status and fingerprint.
+type ErrorCount struct {
+	Type        string `json:"type"`
+	Status      int    `json:"status"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Count       uint64 `json:"count"`
+}
+
+// Observe counts err, unless it is nil.
+func (c *Collector) Observe(err error) {
+	if err == nil {
+		return
+	}
+	eType := getErrType(err)
+	k := metricKey{typ: typeName(eType), status: eType.HTTPStatusCode()}
+	if c.Fingerprint {
+		k.fingerprint = Fingerprint(err)
+	}
+	v, ok := c.counters.Load(k)
+	if !ok {
+		v, _ = c.counters.LoadOrStore(k, new(uint64))
+	}
+	atomic.AddUint64(v.(*uint64), 1)
+}
+
+// Hook returns a Hook counting every created error, to be registered with
+// OnCreate. Note that wrappers such as Wrap and WithSeverity create an error
+// too, so an error wrapped twice is counted three times; use an HTTPWriter
+// to count every failed request once.
+func (c *Collector) Hook() Hook {
+	return func(_ context.Context, err error) { c.Observe(err) }
+}
+
+// Snapshot returns the current counts, sorted by type, status and
+// fingerprint.
+func (c *Collector) Snapshot() []ErrorCount {
+	var counts []ErrorCount
+	c.counters.Range(func(k, v interface{}) bool {
+		key := k.(metricKey)
+		counts = append(counts, ErrorCount{
+			Type:        key.typ,
+			Status:      key.status,
+			Fingerprint: key.fingerprint,
+			Count:       atomic.LoadUint64(v.(*uint64)),
+		})
+		return true
+	})
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	return counts
+}
+
+// String returns the snapshot of c as JSON, it implements expvar.Var.
+func (c *Collector) String() string {
+	counts := c.Snapshot()
+	if counts == nil {
+		counts = []ErrorCount{}
+	}
+	b, _ := json.Marshal(counts)
+	return string(b)
+}
+
+// Publish publishes c as the expvar variable name. Like expvar.Publish, it
+// panics if the name is already in use.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, c)
+}
+
+// ServeHTTP writes the counts in the Prometheus text exposition format, as a
+// counter labelled with type, status and, if enabled, fingerprint.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.writePrometheus(w)
+}
+
+func (c *Collector) writePrometheus(w io.Writer) error {
+	name := c.Name
+	if name == "" {
+		name = "errors_total"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s Number of errors by type and HTTP status.\n", name)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", name)
+	for _, count := range c.Snapshot() {
+		b.WriteString(name)
+		b.WriteString(`{type="`)
+		b.WriteString(escapeLabel(count.Type))
+		b.WriteString(`",status="`)
+		b.WriteString(strconv.Itoa(count.Status))
+		if count.Fingerprint != "" {
+			b.WriteString(`",fingerprint="`)
+			b.WriteString(escapeLabel(count.Fingerprint))
+		}
+		b.WriteString(`"} `)
+		b.WriteString(strconv.FormatUint(count.Count, 10))
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value of the Prometheus text format.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
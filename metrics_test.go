@@ -0,0 +1,138 @@
+package errors
+
+import (
+	"encoding/json"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCollectorSnapshot(t *testing.T) {
+	var c Collector
+	c.Observe(NotFound("x"))
+	c.Observe(Wrap(NotFound("y"), "z"))
+	c.Observe(Unavailable("x"))
+	c.Observe(io.EOF)
+	c.Observe(nil)
+
+	want := []ErrorCount{
+		{Type: "internal", Status: 500, Count: 1},
+		{Type: "not_found", Status: 404, Count: 2},
+		{Type: "unavailable", Status: 503, Count: 1},
+	}
+	if got := c.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot: got %v, want %v", got, want)
+	}
+}
+
+func TestCollectorFingerprint(t *testing.T) {
+	c := Collector{Fingerprint: true}
+	for i := 0; i < 2; i++ {
+		c.Observe(NotFound("x"))
+	}
+	c.Observe(NotFound("x"))
+
+	got := c.Snapshot()
+	if len(got) != 2 || got[0].Fingerprint == "" || got[0].Count+got[1].Count != 3 {
+		t.Errorf("Snapshot: got %v", got)
+	}
+}
+
+func TestCollectorConcurrent(t *testing.T) {
+	var c Collector
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Observe(Timeout("x"))
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Snapshot(); len(got) != 1 || got[0].Count != 800 {
+		t.Errorf("Snapshot: got %v", got)
+	}
+}
+
+func TestCollectorHook(t *testing.T) {
+	var c Collector
+	remove := OnCreate(c.Hook())
+	Input("x")
+	remove()
+	Input("y")
+	if got := c.Snapshot(); len(got) != 1 || got[0].Type != "input" || got[0].Count != 1 {
+		t.Errorf("Snapshot: got %v", got)
+	}
+}
+
+func TestCollectorExpvar(t *testing.T) {
+	var c Collector
+	c.Publish("errors_test_collector")
+	c.Observe(NotFound("x"))
+
+	var got []ErrorCount
+	if err := json.Unmarshal([]byte(expvar.Get("errors_test_collector").String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Type != "not_found" || got[0].Count != 1 {
+		t.Errorf("expvar: got %v", got)
+	}
+}
+
+func TestCollectorServeHTTP(t *testing.T) {
+	c := Collector{Name: "api_errors_total"}
+	c.Observe(NotFound("x"))
+	c.Observe(NotFound("x"))
+	c.Observe(Opaque(io.EOF, NewCustomType(`a "b"`, 502)))
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type: got %q", got)
+	}
+	want := "# HELP api_errors_total Number of errors by type and HTTP status.\n" +
+		"# TYPE api_errors_total counter\n" +
+		`api_errors_total{type="a \"b\"",status="502"} 1` + "\n" +
+		`api_errors_total{type="not_found",status="404"} 2` + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("ServeHTTP:\n got %q\n want %q", got, want)
+	}
+}
+
+func TestHTTPWriterMetrics(t *testing.T) {
+	var c Collector
+	hw := HTTPWriter{Metrics: &c}
+	hw.Write(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), NoPermission("x"))
+	if got := c.Snapshot(); len(got) != 1 || got[0].Status != http.StatusForbidden {
+		t.Errorf("Snapshot: got %v", got)
+	}
+}
+
+type countingScrubber struct{ calls *int }
+
+func (s countingScrubber) Scrub(str string) string {
+	*s.calls++
+	return str
+}
+
+func TestCollectorObserveNoScrubbing(t *testing.T) {
+	calls := 0
+	defer SetScrubbers(getScrubbers()...)
+	SetScrubbers(countingScrubber{&calls})
+
+	var c Collector
+	c.Observe(NotFound("user jane@example.com not found"))
+	if calls != 0 {
+		t.Errorf("Observe: scrubbers called %d times, want 0", calls)
+	}
+	if got := c.Snapshot(); len(got) != 1 || got[0].Status != 404 || got[0].Type != "not_found" {
+		t.Errorf("Snapshot: got %v", got)
+	}
+}
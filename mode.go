@@ -0,0 +1,110 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Mode controls how much of an error is rendered in HTTP responses. Logs
+// are not affected: Handler always logs errors in full.
+type Mode int32
+
+const (
+	// ModeUnset is the zero Mode. An HTTPWriter with ModeUnset uses the mode
+	// of the default Factory, and a Config with ModeUnset uses Standard.
+	ModeUnset Mode = iota
+	// Standard renders the message returned by GetAPIError, which includes
+	// the messages of the causes. It is the default mode.
+	Standard
+	// Production renders the message of the outermost error only, without
+	// the messages of its causes, and sets only the headers of the
+	// HeaderSetters whose message is rendered; Retry-After, RateLimit and
+	// WWW-Authenticate headers are kept. Errors of internal types, such
+	// as TypeInternal, are rendered with DefaultMessage, the type internal
+	// and a correlation ID, which is the ID of the request found in the
+	// RequestIDHeaders of the Config, or the incident ID. Such errors always
+	// get an incident ID, so that the response can be matched with the logs.
+	Production
+	// Development renders the message returned by GetAPIError together with
+	// the error formatted with %+v, stack traces included.
+	Development
+)
+
+// SetMode sets the Mode of the default Factory's Config, used by WriteHTTP
+// and by every HTTPWriter without a mode or a Factory of its own.
+func SetMode(m Mode) {
+	updateDefault(func(cfg *Config) { cfg.Mode = m })
+}
+
+// isInternalType reports whether et is TypeInternal, or is marked internal
+// by an IsInternal method returning true, see CustomType.
+func isInternalType(et Typer) bool {
+	if et == TypeInternal {
+		return true
+	}
+	i, ok := et.(interface {
+		IsInternal() bool
+	})
+	return ok && i.IsInternal()
+}
+
+// ownMessage returns the message of the outermost error in err's chain,
+// without the messages of its causes. Wrappers which do not change the
+// message, such as the one added by WithStack, are skipped. It returns an
+// empty string if the message is that of the errors inside a boundary.
+func ownMessage(err error) string {
+	msg := err.Error()
+	for e := err; !isBoundary(e); {
+		cause := Unwrap(e)
+		if cause == nil {
+			return msg
+		}
+		if causeMsg := cause.Error(); causeMsg != msg {
+			return strings.TrimSuffix(msg, ": "+causeMsg)
+		}
+		e = cause
+	}
+	return ""
+}
+
+// ownLayer returns a function which is like unwrapOutside, but also returns
+// nil once the message changes, so that only the errors whose message is
+// rendered by ownMessage are visited.
+func ownLayer(err error) func(error) error {
+	msg := err.Error()
+	return func(e error) error {
+		cause := unwrapOutside(e)
+		if cause == nil || cause.Error() != msg {
+			return nil
+		}
+		return cause
+	}
+}
+
+// redacted reports whether the message of err is replaced by DefaultMessage
+// in mode m. It is decided from what produced the status returned by
+// GetAPIError: err's type, or else an APIError of another package without a
+// type, which is redacted if its status is a server error.
+func redacted(err error, m Mode) bool {
+	if m != Production {
+		return false
+	}
+	if apiErr := foreignAPIError(err); apiErr != nil {
+		code, _ := apiErr.APIError()
+		return code >= http.StatusInternalServerError
+	}
+	return isInternalType(getErrType(err))
+}
+
+// correlationID returns the ID of r found in the RequestIDHeaders of f, or
+// the incident ID if r is nil or carries none.
+func (f *Factory) correlationID(r *http.Request, incident string) string {
+	if r != nil {
+		for _, name := range f.cfg.RequestIDHeaders {
+			if id := r.Header.Get(name); id != "" {
+				return id
+			}
+		}
+	}
+	return incident
+}
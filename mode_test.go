@@ -0,0 +1,144 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOwnMessage(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{New("x"), "x"},
+		{Wrap(New("x"), "y"), "y"},
+		{WithStack(WithMessage(io.EOF, "read")), "read"},
+		{WithStack(io.EOF), "EOF"},
+		{WithMessage(WithMessage(io.EOF, "a"), "b"), "b"},
+		{LimitExceededAfter(0, "slow down"), "slow down"},
+	}
+	for i, tt := range tests {
+		if got := ownMessage(tt.err); got != tt.want {
+			t.Errorf("test %d: ownMessage: got %q, want %q", i+1, got, tt.want)
+		}
+	}
+}
+
+func writeMode(m Mode, r *http.Request, err error) (*httptest.ResponseRecorder, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	HTTPWriter{Mode: m}.Write(rec, r, err)
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec, body
+}
+
+func TestModeProduction(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	_, body := writeMode(Production, r, Wrap(NotFound("no such user"), "load profile"))
+	if body["message"] != "load profile" || body["type"] != "not_found" || body["correlation_id"] != nil || body["debug"] != nil {
+		t.Errorf("not found: got %v", body)
+	}
+
+	rec, body := writeMode(Production, r, Wrap(io.ErrUnexpectedEOF, "query users table"))
+	if rec.Code != http.StatusInternalServerError || body["message"] != DefaultMessage || body["type"] != "internal" {
+		t.Errorf("internal: got %d %v", rec.Code, body)
+	}
+	if id, _ := body["correlation_id"].(string); !regexp.MustCompile("^[0-9a-f]{32}$").MatchString(id) {
+		t.Errorf("internal: correlation_id: got %v", body["correlation_id"])
+	}
+
+	rec, body = writeMode(Production, r, fmt.Errorf("load: %w", NotFound("user 42")))
+	if rec.Code != http.StatusNotFound || body["message"] == DefaultMessage || body["type"] != "not_found" || body["correlation_id"] != nil {
+		t.Errorf("foreign wrapper: got %d %v", rec.Code, body)
+	}
+
+	for _, code := range []int{http.StatusNotFound, http.StatusServiceUnavailable} {
+		rec, body = writeMode(Production, r, fmt.Errorf("load: %w", statusError(code)))
+		if want := code >= 500; rec.Code != code || (body["message"] == DefaultMessage) != want {
+			t.Errorf("foreign APIError %d: got %d %v", code, rec.Code, body)
+		}
+	}
+
+	secret := NewCustomType("database", http.StatusBadGateway)
+	secret.Internal = true
+	r.Header.Set("X-Request-Id", "req-7")
+	rec, body = writeMode(Production, r, WrapType(io.EOF, secret, "replica lag"))
+	if rec.Code != http.StatusBadGateway || body["message"] != DefaultMessage || body["type"] != "internal" || body["correlation_id"] != "req-7" {
+		t.Errorf("internal custom type: got %d %v", rec.Code, body)
+	}
+	if strings.Contains(rec.Body.String(), "replica") || strings.Contains(rec.Body.String(), "database") {
+		t.Errorf("internal custom type: body leaks details: %s", rec.Body)
+	}
+}
+
+// statusError is an APIError without a type.
+type statusError int
+
+func (e statusError) Error() string { return http.StatusText(int(e)) }
+
+func (e statusError) APIError() (int, string) { return int(e), e.Error() }
+
+func TestModeDevelopment(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, body := writeMode(Development, r, Wrap(io.EOF, "read"))
+	debug, _ := body["debug"].(string)
+	if body["message"] != "read: EOF" || !strings.Contains(debug, "TestModeDevelopment") {
+		t.Errorf("development: got %v", body)
+	}
+}
+
+func TestSetMode(t *testing.T) {
+	defer SetDefault(Default())
+	SetMode(Production)
+
+	rec := httptest.NewRecorder()
+	WriteHTTP(rec, Internal("disk full"))
+	if got := rec.Body.String(); !strings.HasPrefix(got, DefaultMessage+"\ncorrelation id: ") {
+		t.Errorf("WriteHTTP: got %q", got)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, body := writeMode(ModeUnset, r, Wrap(NotFound("x"), "y")); body["message"] != "y" {
+		t.Errorf("HTTPWriter: got %v", body)
+	}
+	if _, body := writeMode(Standard, r, Wrap(NotFound("x"), "y")); body["message"] != "y: x" {
+		t.Errorf("HTTPWriter with Standard: got %v", body)
+	}
+	if _, body := writeMode(Development, r, Wrap(NotFound("x"), "y")); body["message"] != "y: x" {
+		t.Errorf("HTTPWriter with Development: got %v", body)
+	}
+	if got := Default().Config().Mode; got != Production {
+		t.Errorf("Config().Mode: got %v, want %v", got, Production)
+	}
+}
+
+func TestModeProductionHeaders(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	tests := []struct {
+		err    error
+		header string
+		want   string
+	}{
+		{Wrap(LimitExceededAfter(time.Minute, "slow down"), "call billing"), "Retry-After", "60"},
+		{LimitExceededAfter(time.Minute, "slow down"), "Retry-After", "60"},
+		{Wrap(WithHeader(NotFound("x"), "Location", "/y"), "z"), "Location", ""},
+		{WithHeader(Wrap(NotFound("x"), "z"), "Location", "/y"), "Location", "/y"},
+		{Wrap(UnauthenticatedChallenge(Challenge{Scheme: "Bearer"}, "no token"), "get user"), "WWW-Authenticate", "Bearer"},
+	}
+	for i, tt := range tests {
+		if rec, _ := writeMode(Production, r, tt.err); rec.Header().Get(tt.header) != tt.want {
+			t.Errorf("test %d: %s: got %q, want %q", i+1, tt.header, rec.Header().Get(tt.header), tt.want)
+		}
+	}
+	if rec, _ := writeMode(Standard, r, tests[2].err); rec.Header().Get("Location") != "/y" {
+		t.Errorf("Standard: Location: got %q, want %q", rec.Header().Get("Location"), "/y")
+	}
+}
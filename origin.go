@@ -0,0 +1,120 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// SetOriginCapture enables or disables the capture of the creation time and
+// goroutine ID by every constructor which records a stack trace, by setting
+// the Stack.Origin option of the default Factory.
+// Capture is disabled by default; use WithOrigin to capture it for a single error.
+func SetOriginCapture(enabled bool) {
+	updateDefault(func(cfg *Config) { cfg.Stack.Origin = enabled })
+}
+
+// origin records when and on which goroutine an error was created.
+type origin struct {
+	time      time.Time
+	goroutine uint64
+}
+
+// captureOrigin returns the current origin if capture is enabled for the
+// default Factory, otherwise nil.
+func captureOrigin() *origin {
+	return Default().captureOrigin()
+}
+
+func newOrigin() *origin {
+	return &origin{
+		time:      time.Now(),
+		goroutine: goroutineID(),
+	}
+}
+
+// captured returns o, it is promoted to every error type embedding *origin.
+func (o *origin) captured() *origin { return o }
+
+func (o *origin) Format(s fmt.State, verb rune) {
+	if o == nil {
+		return
+	}
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, "\ncreated at ")
+			io.WriteString(s, o.time.Format(time.RFC3339Nano))
+			io.WriteString(s, " on goroutine ")
+			io.WriteString(s, strconv.FormatUint(o.goroutine, 10))
+		}
+	}
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// header of its stack dump, or zero if it cannot be determined.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// WithOrigin annotates err with the time and goroutine at the point WithOrigin
+// was called, regardless of whether capture is enabled by SetOriginCapture.
+// If err is nil, WithOrigin returns nil.
+func WithOrigin(err error) error {
+	if err == nil {
+		return nil
+	}
+	err = withMetadata(err, newOrigin())
+	Default().created(context.Background(), err)
+	return err
+}
+
+// originOf returns the innermost origin recorded in err's chain, or nil.
+func originOf(err error) *origin {
+	var o *origin
+	for err != nil {
+		if c, ok := metaOf(err).(interface {
+			captured() *origin
+		}); ok && c.captured() != nil {
+			o = c.captured()
+		}
+		err = Unwrap(err)
+	}
+	return o
+}
+
+// Timestamp returns the time at which the innermost error in err's chain
+// with a recorded origin was created. The time carries a monotonic clock
+// reading when compared with other times taken by the same process.
+// The boolean is false if no origin was recorded.
+func Timestamp(err error) (time.Time, bool) {
+	o := originOf(err)
+	if o == nil {
+		return time.Time{}, false
+	}
+	return o.time, true
+}
+
+// GoroutineID returns the ID of the goroutine on which the innermost error in
+// err's chain with a recorded origin was created.
+// The boolean is false if no origin was recorded.
+func GoroutineID(err error) (uint64, bool) {
+	o := originOf(err)
+	if o == nil {
+		return 0, false
+	}
+	return o.goroutine, true
+}
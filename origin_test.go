@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestOriginCaptureDisabled(t *testing.T) {
+	for _, err := range []error{New("x"), Wrap(io.EOF, "x"), WithStack(io.EOF), NotFound("x")} {
+		if _, ok := Timestamp(err); ok {
+			t.Errorf("Timestamp(%v): origin recorded while capture is disabled", err)
+		}
+		if _, ok := GoroutineID(err); ok {
+			t.Errorf("GoroutineID(%v): origin recorded while capture is disabled", err)
+		}
+	}
+}
+
+func TestOriginCapture(t *testing.T) {
+	SetOriginCapture(true)
+	defer SetOriginCapture(false)
+
+	before := time.Now()
+	inner := make(chan error)
+	go func() { inner <- New("inner") }()
+	err := Wrap(<-inner, "outer")
+
+	ts, ok := Timestamp(err)
+	if !ok || ts.Before(before) || ts.After(time.Now()) {
+		t.Errorf("Timestamp: got %v, %v", ts, ok)
+	}
+	innerID, _ := GoroutineID(err)
+	if innerID == 0 || innerID == goroutineID() {
+		t.Errorf("GoroutineID: got %d, want the ID of the goroutine calling New", innerID)
+	}
+	if outer := err.(*withStack).origin; outer == nil || outer.goroutine != goroutineID() {
+		t.Errorf("Wrap did not record the origin of the calling goroutine")
+	}
+
+	want := regexp.MustCompile(`^inner\ncreated at \S+ on goroutine \d+\n`)
+	if got := fmt.Sprintf("%+v", Cause(err)); !want.MatchString(got) {
+		t.Errorf("%%+v: got %q, want match for %q", got, want)
+	}
+}
+
+func TestWithOrigin(t *testing.T) {
+	if got := WithOrigin(nil); got != nil {
+		t.Errorf("WithOrigin(nil): got %#v, want nil", got)
+	}
+	err := WithOrigin(io.EOF)
+	if id, ok := GoroutineID(err); !ok || id != goroutineID() {
+		t.Errorf("GoroutineID: got %d, %v, want %d", id, ok, goroutineID())
+	}
+	if err.Error() != "EOF" || Cause(err) != io.EOF {
+		t.Errorf("WithOrigin: got %v, cause %v", err, Cause(err))
+	}
+	want := regexp.MustCompile(`^EOF\ncreated at \S+ on goroutine \d+$`)
+	if got := fmt.Sprintf("%+v", err); !want.MatchString(got) {
+		t.Errorf("%%+v: got %q, want match for %q", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit describes the rate limit which caused a TypeLimitExceeded error.
+type RateLimit struct {
+	// Limit is the request quota of the current window, zero if unknown.
+	Limit int
+	// Remaining is the quota left in the current window.
+	Remaining int
+	// Reset is the time left until the quota is reset.
+	Reset time.Duration
+	// RetryAfter is the time the client should wait before retrying.
+	RetryAfter time.Duration
+	// RetryAt is the time after which the client may retry. It takes
+	// precedence over RetryAfter when set.
+	RetryAt time.Time
+}
+
+// LimitExceededAfter creates a new error of type TypeLimitExceeded telling the
+// client to retry after d.
+func LimitExceededAfter(d time.Duration, message string) error {
+	return Default().newAnnotated(context.Background(), message, template{}, TypeLimitExceeded, withRateLimit(RateLimit{RetryAfter: d}))
+}
+
+// LimitExceededUntil creates a new error of type TypeLimitExceeded telling the
+// client to retry after t.
+func LimitExceededUntil(t time.Time, message string) error {
+	return Default().newAnnotated(context.Background(), message, template{}, TypeLimitExceeded, withRateLimit(RateLimit{RetryAt: t}))
+}
+
+// LimitExceededRate creates a new error of type TypeLimitExceeded carrying rl.
+func LimitExceededRate(rl RateLimit, message string) error {
+	return Default().newAnnotated(context.Background(), message, template{}, TypeLimitExceeded, withRateLimit(rl))
+}
+
+// LimitExceededRatef creates a new error of type TypeLimitExceeded carrying rl,
+// with formatted message.
+func LimitExceededRatef(rl RateLimit, format string, args ...interface{}) error {
+	return Default().newAnnotated(context.Background(), fmt.Sprintf(format, args...), template{format, args}, TypeLimitExceeded, withRateLimit(rl))
+}
+
+// withRateLimit returns a function annotating an error with rl.
+func withRateLimit(rl RateLimit) func(error) error {
+	return func(err error) error {
+		return withMetadata(err, rateLimit{rl})
+	}
+}
+
+// rateLimit is the metadata added by withRateLimit.
+type rateLimit struct {
+	rl RateLimit
+}
+
+// RetryAfter returns the time left until the client may retry.
+func (r rateLimit) RetryAfter() time.Duration {
+	if !r.rl.RetryAt.IsZero() {
+		if d := time.Until(r.rl.RetryAt); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return r.rl.RetryAfter
+}
+
+func (r rateLimit) RateLimit() RateLimit { return r.rl }
+
+// RetryAfter returns the retry-after hint of the outermost error in err's
+// chain which carries one, as set by LimitExceededAfter, LimitExceededUntil
+// or any error implementing
+//
+//	interface {
+//		RetryAfter() time.Duration
+//	}
+//
+// The boolean is false if no error in the chain carries a hint. Errors inside
+// a boundary created by Opaque or Boundary are ignored.
+func RetryAfter(err error) (time.Duration, bool) {
+	for err != nil {
+		if r, ok := metaOf(err).(interface {
+			RetryAfter() time.Duration
+		}); ok {
+			return r.RetryAfter(), true
+		}
+		err = unwrapOutside(err)
+	}
+	return 0, false
+}
+
+// GetRateLimit returns the RateLimit of the outermost error in err's chain
+// which carries one, ignoring the errors inside a boundary.
+func GetRateLimit(err error) (RateLimit, bool) {
+	for err != nil {
+		if r, ok := metaOf(err).(interface {
+			RateLimit() RateLimit
+		}); ok {
+			return r.RateLimit(), true
+		}
+		err = unwrapOutside(err)
+	}
+	return RateLimit{}, false
+}
+
+// setRateLimitHeaders sets the Retry-After and RateLimit-* headers from the
+// rate limit carried by err, if any.
+func setRateLimitHeaders(h http.Header, err error) {
+	rl, hasRL := GetRateLimit(err)
+	switch {
+	case hasRL && !rl.RetryAt.IsZero():
+		h.Set("Retry-After", rl.RetryAt.UTC().Format(http.TimeFormat))
+	default:
+		if d, ok := RetryAfter(err); ok {
+			h.Set("Retry-After", seconds(d))
+		}
+	}
+	if !hasRL || rl.Limit <= 0 {
+		return
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(rl.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(rl.Remaining))
+	h.Set("RateLimit-Reset", seconds(rl.Reset))
+}
+
+// seconds formats d as a non-negative number of seconds, rounded up.
+func seconds(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
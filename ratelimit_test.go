@@ -0,0 +1,109 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{nil, 0, false},
+		{LimitExceeded("slow down"), 0, false},
+		{LimitExceededAfter(3*time.Second, "slow down"), 3 * time.Second, true},
+		{Wrap(LimitExceededAfter(3*time.Second, "slow down"), "call"), 3 * time.Second, true},
+		{LimitExceededUntil(time.Now().Add(-time.Second), "slow down"), 0, true},
+		{LimitExceededRate(RateLimit{Limit: 10, RetryAfter: time.Minute}, "slow down"), time.Minute, true},
+	}
+	for _, tt := range tests {
+		got, ok := RetryAfter(tt.err)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("RetryAfter(%v): got %v, %v, want %v, %v", tt.err, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	d, _ := RetryAfter(LimitExceededUntil(time.Now().Add(time.Hour), "slow down"))
+	if d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("RetryAfter(LimitExceededUntil): got %v, want about an hour", d)
+	}
+}
+
+func TestLimitExceededType(t *testing.T) {
+	err := Wrap(LimitExceededRatef(RateLimit{Limit: 10}, "%d requests", 11), "call")
+	if !HasType(err, TypeLimitExceeded) || !IsRetryable(err) {
+		t.Errorf("LimitExceededRatef: %v is not a retryable TypeLimitExceeded error", err)
+	}
+	if code, msg := GetAPIError(err); code != http.StatusTooManyRequests || msg != "call: 11 requests" {
+		t.Errorf("GetAPIError: got %d, %q", code, msg)
+	}
+	if rl, ok := GetRateLimit(err); !ok || rl.Limit != 10 {
+		t.Errorf("GetRateLimit: got %+v, %v", rl, ok)
+	}
+}
+
+func TestWriteHTTPRateLimit(t *testing.T) {
+	at := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		err  error
+		want map[string]string
+	}{{
+		name: "retry after",
+		err:  LimitExceededAfter(1500*time.Millisecond, "slow down"),
+		want: map[string]string{"Retry-After": "2", "RateLimit-Limit": ""},
+	}, {
+		name: "retry at",
+		err:  LimitExceededUntil(at, "slow down"),
+		want: map[string]string{"Retry-After": "Wed, 02 Jan 2030 03:04:05 GMT"},
+	}, {
+		name: "rate limit",
+		err: LimitExceededRate(RateLimit{
+			Limit:      100,
+			Remaining:  0,
+			Reset:      30 * time.Second,
+			RetryAfter: 30 * time.Second,
+		}, "slow down"),
+		want: map[string]string{
+			"Retry-After":         "30",
+			"RateLimit-Limit":     "100",
+			"RateLimit-Remaining": "0",
+			"RateLimit-Reset":     "30",
+		},
+	}, {
+		name: "plain",
+		err:  LimitExceeded("slow down"),
+		want: map[string]string{"Retry-After": "", "RateLimit-Limit": ""},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteHTTP(rec, tt.err)
+			if rec.Code != http.StatusTooManyRequests {
+				t.Errorf("status: got %d, want %d", rec.Code, http.StatusTooManyRequests)
+			}
+			for k, v := range tt.want {
+				if got := rec.Header().Get(k); got != v {
+					t.Errorf("%s: got %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestLimitExceededRateStack(t *testing.T) {
+	for _, err := range []error{
+		LimitExceededRate(RateLimit{}, "slow down"),
+		UnauthenticatedChallenge(Challenge{Scheme: "Bearer"}, "slow down"),
+	} {
+		if got, want := fmt.Sprintf("%+v", err), "slow down\ngithub.com/bynil/errors.TestLimitExceededRateStack\n"; !strings.HasPrefix(got, want) {
+			t.Errorf("%%+v: got %q, want prefix %q", got, want)
+		}
+	}
+}
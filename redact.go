@@ -0,0 +1,160 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sync/atomic"
+)
+
+// RedactedText replaces secrets and the matches of scrubbers.
+const RedactedText = "[REDACTED]"
+
+// Secret holds a sensitive value, such as a token, which is printed as
+// [REDACTED] by the fmt verbs and encoded as "[REDACTED]" in JSON and by
+// log/slog. Use it for the arguments of Errorf and Wrapf, and for field
+// values, which must never be rendered.
+type Secret struct {
+	value interface{}
+}
+
+// Redacted returns a Secret holding v.
+func Redacted(v interface{}) Secret {
+	return Secret{v}
+}
+
+// Value returns the value held by s.
+func (s Secret) Value() interface{} { return s.value }
+
+func (s Secret) String() string { return RedactedText }
+
+// GoString implements fmt.GoStringer.
+func (s Secret) GoString() string { return RedactedText }
+
+// Format prints [REDACTED] for every verb.
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'q' {
+		fmt.Fprintf(f, "%q", RedactedText)
+		return
+	}
+	io.WriteString(f, RedactedText)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + RedactedText + `"`), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(RedactedText), nil
+}
+
+// Scrubber removes sensitive data from a text.
+type Scrubber interface {
+	Scrub(s string) string
+}
+
+// RegexpScrubber replaces the matches of Pattern with Replacement, in which
+// $1 denotes the first submatch as in regexp.Regexp.ReplaceAllString.
+type RegexpScrubber struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+func (r RegexpScrubber) Scrub(s string) string {
+	return r.Pattern.ReplaceAllString(s, r.Replacement)
+}
+
+var defaultScrubbers = []Scrubber{
+	RegexpScrubber{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), RedactedText},
+	RegexpScrubber{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), RedactedText},
+	RegexpScrubber{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`), "$1 " + RedactedText},
+	RegexpScrubber{regexp.MustCompile(`(?i)\b((?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']?)[^\s"'&,;]+`), "${1}" + RedactedText},
+	RegexpScrubber{regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), RedactedText},
+	cardScrubber{},
+}
+
+// DefaultScrubbers returns a set of scrubbers replacing email addresses, JSON
+// web tokens, the credentials of Authorization headers, values of keys such
+// as password or api_key, US social security numbers and payment card numbers
+// passing the Luhn check. Note that about one in ten other numbers of 13 to
+// 19 digits, such as IDs, pass the Luhn check too and are replaced.
+//
+// Scrubbing is disabled unless enabled by SetScrubbers, e.g. with
+//
+//	errors.SetScrubbers(errors.DefaultScrubbers()...)
+func DefaultScrubbers() []Scrubber {
+	return append([]Scrubber(nil), defaultScrubbers...)
+}
+
+// scrubbers holds the []Scrubber set by SetScrubbers.
+var scrubbers atomic.Value
+
+// SetScrubbers sets the scrubbers run over the messages and field values of
+// errors before they are rendered by GetAPIError, HTTPWriter, JSON encoding,
+// log/slog, NewSentryEvent and RecordOnSpan. They apply to the whole process,
+// whichever Factory created the errors, as rendering does not know it.
+// Calling it without scrubbers disables scrubbing. It is safe to call
+// concurrently with rendering.
+func SetScrubbers(s ...Scrubber) {
+	scrubbers.Store(append([]Scrubber(nil), s...))
+}
+
+// getScrubbers returns the scrubbers set by SetScrubbers.
+func getScrubbers() []Scrubber {
+	s, _ := scrubbers.Load().([]Scrubber)
+	return s
+}
+
+// Scrub returns s with the sensitive data found by the scrubbers set by
+// SetScrubbers replaced.
+func Scrub(s string) string {
+	for _, scrubber := range getScrubbers() {
+		s = scrubber.Scrub(s)
+	}
+	return s
+}
+
+// scrubValue scrubs v if it is a string.
+func scrubValue(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return Scrub(s)
+	}
+	return v
+}
+
+var cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// cardScrubber replaces payment card numbers, telling them apart from other
+// long numbers by their check digit.
+type cardScrubber struct{}
+
+func (cardScrubber) Scrub(s string) string {
+	return cardPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if luhn(m) {
+			return RedactedText
+		}
+		return m
+	})
+}
+
+// luhn reports whether the digits of s pass the Luhn check.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,354 @@
+package errors
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types of the error response bodies.
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeProblem = "application/problem+json"
+	MediaTypeXML     = "application/xml"
+	MediaTypeText    = "text/plain"
+	MediaTypeHTML    = "text/html"
+)
+
+// response is the data rendered as the body of an error response. Every
+// renderer uses the same data, so that the formats never drift apart.
+type response struct {
+	// Status is the HTTP status code, taken from GetAPIError.
+	Status int
+	// Title is the text of Status, e.g. Not Found.
+	Title string
+	// Type is the name of the error's Typer, e.g. not_found.
+	Type string
+	// Code is the message ID of the error's localize config, if any.
+	Code string
+	// Message is the API message, localized if the error carries a localize config.
+	Message string
+	// CorrelationID identifies the request in the logs, in Production mode.
+	CorrelationID string
+	// IncidentID is the incident ID attached to the error, see AttachIncidentID.
+	IncidentID string
+	// Debug is the error formatted with %+v and scrubbed, in Development mode.
+	Debug string
+}
+
+// newResponse builds the response for err in mode m, localizing its message
+// in the languages of the request r, which may be nil, if err carries a
+// localize config.
+func (f *Factory) newResponse(r *http.Request, code int, err error, m Mode) response {
+	_, msg := GetAPIError(err)
+	eType := getErrType(err)
+	incident := IncidentID(err)
+	if redacted(err, m) {
+		return response{
+			Status:        code,
+			Title:         statusText(code),
+			Type:          typeName(TypeInternal),
+			Message:       DefaultMessage,
+			CorrelationID: f.correlationID(r, incident),
+			IncidentID:    incident,
+		}
+	}
+	if m == Production {
+		msg = ownMessage(err)
+		if msg == "" {
+			msg = statusText(code)
+		}
+		msg = Scrub(msg)
+	}
+	var langs []string
+	if r != nil {
+		langs = append(langs, r.Header.Get("Accept-Language"))
+	}
+	if localized, ok := localize(err, langs...); ok {
+		msg = Scrub(localized)
+	}
+	resp := response{
+		Status:     code,
+		Title:      statusText(code),
+		Type:       typeName(eType),
+		Code:       messageCode(err),
+		Message:    msg,
+		IncidentID: incident,
+	}
+	if m == Development {
+		resp.Debug = Scrub(fmt.Sprintf("%+v", err))
+	}
+	return resp
+}
+
+// messageCode returns the message ID of err's localize config, if any.
+func messageCode(err error) string {
+	lc := localizeConfigOf(err)
+	if lc == nil {
+		return ""
+	}
+	if lc.MessageID == "" && lc.DefaultMessage != nil {
+		return lc.DefaultMessage.ID
+	}
+	return lc.MessageID
+}
+
+// statusText is like http.StatusText, and also knows StatusClientClosedRequest.
+func statusText(code int) string {
+	if code == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(code)
+}
+
+// renderer writes a response body of a single media type.
+type renderer func(w io.Writer, resp response) error
+
+var renderers = map[string]renderer{
+	MediaTypeJSON:    renderJSON,
+	MediaTypeProblem: renderProblem,
+	MediaTypeXML:     renderXML,
+	MediaTypeText:    renderText,
+	MediaTypeHTML:    renderHTML,
+}
+
+// offers lists the supported media types in order of preference.
+var offers = []string{MediaTypeJSON, MediaTypeProblem, MediaTypeXML, MediaTypeHTML, MediaTypeText}
+
+func renderJSON(w io.Writer, resp response) error {
+	return json.NewEncoder(w).Encode(struct {
+		Status        int    `json:"status"`
+		Title         string `json:"title"`
+		Type          string `json:"type"`
+		Code          string `json:"code,omitempty"`
+		Message       string `json:"message"`
+		CorrelationID string `json:"correlation_id,omitempty"`
+		IncidentID    string `json:"incident_id,omitempty"`
+		Debug         string `json:"debug,omitempty"`
+	}{resp.Status, resp.Title, resp.Type, resp.Code, resp.Message, resp.CorrelationID, resp.IncidentID, resp.Debug})
+}
+
+// renderProblem renders resp as problem details, see RFC 9457. The error
+// type, code, correlation and incident IDs and debug output are added as
+// extension members.
+func renderProblem(w io.Writer, resp response) error {
+	return json.NewEncoder(w).Encode(struct {
+		Type          string `json:"type"`
+		Title         string `json:"title"`
+		Status        int    `json:"status"`
+		Detail        string `json:"detail"`
+		ErrorType     string `json:"error_type"`
+		Code          string `json:"code,omitempty"`
+		CorrelationID string `json:"correlation_id,omitempty"`
+		IncidentID    string `json:"incident_id,omitempty"`
+		Debug         string `json:"debug,omitempty"`
+	}{"about:blank", resp.Title, resp.Status, resp.Message, resp.Type, resp.Code, resp.CorrelationID, resp.IncidentID, resp.Debug})
+}
+
+func renderXML(w io.Writer, resp response) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(struct {
+		XMLName       xml.Name `xml:"error"`
+		Status        int      `xml:"status"`
+		Title         string   `xml:"title"`
+		Type          string   `xml:"type"`
+		Code          string   `xml:"code,omitempty"`
+		Message       string   `xml:"message"`
+		CorrelationID string   `xml:"correlation_id,omitempty"`
+		IncidentID    string   `xml:"incident_id,omitempty"`
+		Debug         string   `xml:"debug,omitempty"`
+	}{
+		Status:        resp.Status,
+		Title:         resp.Title,
+		Type:          resp.Type,
+		Code:          resp.Code,
+		Message:       resp.Message,
+		CorrelationID: resp.CorrelationID,
+		IncidentID:    resp.IncidentID,
+		Debug:         resp.Debug,
+	})
+}
+
+func renderText(w io.Writer, resp response) error {
+	text := resp.Message + "\n"
+	if resp.CorrelationID != "" {
+		text += "correlation id: " + resp.CorrelationID + "\n"
+	}
+	if resp.IncidentID != "" {
+		text += "incident id: " + resp.IncidentID + "\n"
+	}
+	if resp.Debug != "" {
+		text += "\n" + resp.Debug + "\n"
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+func renderHTML(w io.Writer, resp response) error {
+	title := html.EscapeString(strconv.Itoa(resp.Status) + " " + resp.Title)
+	body := "<h1>" + title + "</h1><p>" + html.EscapeString(resp.Message) + "</p>"
+	if resp.CorrelationID != "" {
+		body += "<p>Correlation ID: <code>" + html.EscapeString(resp.CorrelationID) + "</code></p>"
+	}
+	if resp.IncidentID != "" {
+		body += "<p>Incident ID: <code>" + html.EscapeString(resp.IncidentID) + "</code></p>"
+	}
+	if resp.Debug != "" {
+		body += "<pre>" + html.EscapeString(resp.Debug) + "</pre>"
+	}
+	_, err := io.WriteString(w, "<!DOCTYPE html>\n"+
+		"<html><head><meta charset=\"utf-8\"><title>"+title+"</title></head>\n"+
+		"<body>"+body+"</body></html>\n")
+	return err
+}
+
+// writeResponse writes resp with the status and the media type mediaType,
+// which must be one of offers.
+func writeResponse(w http.ResponseWriter, resp response, mediaType string) {
+	h := w.Header()
+	if strings.HasPrefix(mediaType, "text/") {
+		h.Set("Content-Type", mediaType+"; charset=utf-8")
+	} else {
+		h.Set("Content-Type", mediaType)
+	}
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Del("Content-Length")
+	w.WriteHeader(resp.Status)
+	renderers[mediaType](w, resp)
+}
+
+// HTTPWriter writes errors as HTTP responses in the media type negotiated
+// from the request's Accept header.
+type HTTPWriter struct {
+	// DefaultMediaType is used when the request has no Accept header, accepts
+	// any media type or none of the supported ones. It must be one of the
+	// MediaType constants, MediaTypeJSON is used if it is empty.
+	DefaultMediaType string
+	// Metrics, if not nil, counts every written error.
+	Metrics *Collector
+	// Mode overrides the Mode of the Factory, unless it is ModeUnset.
+	Mode Mode
+	// Factory provides the Mode, IncidentHeader and RequestIDHeaders of the
+	// responses, and the HandlerLog of the Handler using the HTTPWriter.
+	// The default Factory is used if nil.
+	Factory *Factory
+}
+
+// Write writes err as the response to r. The status code is taken from
+// GetAPIError and the headers are set as by WriteHTTP. The message depends
+// on the Mode, and is localized in the languages of the request's
+// Accept-Language header if the error carries a localize config. Errors
+// with a 5xx status, and those whose message is redacted in Production
+// mode, get an incident ID from AttachIncidentID, using the context of r.
+func (hw HTTPWriter) Write(w http.ResponseWriter, r *http.Request, err error) {
+	if hw.Metrics != nil {
+		hw.Metrics.Observe(err)
+	}
+	f, m := hw.factory(), hw.mode()
+	err = attachIncidentID(r.Context(), err, m)
+	code, _ := GetAPIError(err)
+	f.setHeaders(w.Header(), code, err, m)
+	resp := f.newResponse(r, code, err, m)
+	writeResponse(w, resp, hw.negotiate(r.Header.Get("Accept")))
+}
+
+func (hw HTTPWriter) mode() Mode {
+	if hw.Mode != ModeUnset {
+		return hw.Mode
+	}
+	return hw.factory().cfg.Mode
+}
+
+func (hw HTTPWriter) factory() *Factory {
+	if hw.Factory != nil {
+		return hw.Factory
+	}
+	return Default()
+}
+
+// negotiate returns the media type of the response to a request with the
+// Accept header value accept.
+func (hw HTTPWriter) negotiate(accept string) string {
+	def := hw.DefaultMediaType
+	if _, ok := renderers[def]; !ok {
+		def = MediaTypeJSON
+	}
+	preferred := make([]string, 0, len(offers))
+	preferred = append(preferred, def)
+	for _, offer := range offers {
+		if offer != def {
+			preferred = append(preferred, offer)
+		}
+	}
+	return negotiate(accept, preferred, def)
+}
+
+// negotiate returns the media type of offers preferred by the Accept header
+// value accept, taking q-values and the specificity of media ranges into
+// account. Ties are broken by the order of offers. It returns def if accept
+// is empty or none of offers is acceptable.
+func negotiate(accept string, offers []string, def string) string {
+	if strings.TrimSpace(accept) == "" {
+		return def
+	}
+	type mediaRange struct {
+		typ, subtype string
+		q            float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		r := mediaRange{q: 1}
+		r.typ, r.subtype = splitMediaType(mt)
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil {
+				r.q = v
+			}
+		}
+		ranges = append(ranges, r)
+	}
+
+	best, bestQ := def, 0.0
+	for _, offer := range offers {
+		typ, subtype := splitMediaType(offer)
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			var s int
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case r.typ == typ && r.subtype == "*":
+				s = 1
+			case r.typ == "*" && r.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+func splitMediaType(mt string) (typ, subtype string) {
+	if i := strings.IndexByte(mt, '/'); i >= 0 {
+		return mt[:i], mt[i+1:]
+	}
+	return mt, ""
+}
@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", MediaTypeText},
+		{"*/*", MediaTypeJSON},
+		{"application/json", MediaTypeJSON},
+		{"application/problem+json", MediaTypeProblem},
+		{"application/problem+json, application/json;q=0.9", MediaTypeProblem},
+		{"application/json;q=0.5, text/plain", MediaTypeText},
+		{"text/*", MediaTypeHTML},
+		{"text/plain, text/*;q=0.5", MediaTypeText},
+		{"text/html, */*;q=0.1", MediaTypeHTML},
+		{"application/*;q=0.2, application/problem+json;q=0.8", MediaTypeProblem},
+		{"application/json;q=0, */*", MediaTypeProblem},
+		{"application/xml;q=0.9, application/json;q=0.8", MediaTypeXML},
+		{"image/png", MediaTypeText},
+		{"not a media type", MediaTypeText},
+	}
+	for _, tt := range tests {
+		if got := negotiate(tt.accept, offers, MediaTypeText); got != tt.want {
+			t.Errorf("negotiate(%q): got %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPWriterNegotiate(t *testing.T) {
+	tests := []struct {
+		def    string
+		accept string
+		want   string
+	}{
+		{"", "", MediaTypeJSON},
+		{"", "*/*", MediaTypeJSON},
+		{MediaTypeProblem, "", MediaTypeProblem},
+		{MediaTypeProblem, "*/*", MediaTypeProblem},
+		{MediaTypeProblem, "image/png", MediaTypeProblem},
+		{MediaTypeProblem, "text/plain", MediaTypeText},
+		{"application/unknown", "", MediaTypeJSON},
+	}
+	for _, tt := range tests {
+		if got := (HTTPWriter{DefaultMediaType: tt.def}).negotiate(tt.accept); got != tt.want {
+			t.Errorf("negotiate(%q) with default %q: got %q, want %q", tt.accept, tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	err := WithMessage(NewI18n(TypeNotFound, &i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{ID: "UserNotFound", Other: "user <b> not found"},
+	}), "lookup")
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{MediaTypeJSON, `{"status":404,"title":"Not Found","type":"not_found","code":"UserNotFound","message":"user \u003cb\u003e not found"}` + "\n"},
+		{MediaTypeProblem, `{"type":"about:blank","title":"Not Found","status":404,"detail":"user \u003cb\u003e not found","error_type":"not_found","code":"UserNotFound"}` + "\n"},
+		{MediaTypeXML, xml.Header + `<error><status>404</status><title>Not Found</title><type>not_found</type><code>UserNotFound</code><message>user &lt;b&gt; not found</message></error>`},
+		{MediaTypeText, "user <b> not found\n"},
+		{MediaTypeHTML, "<!DOCTYPE html>\n" +
+			`<html><head><meta charset="utf-8"><title>404 Not Found</title></head>` + "\n" +
+			`<body><h1>404 Not Found</h1><p>user &lt;b&gt; not found</p></body></html>` + "\n"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		HTTPWriter{}.Write(rec, r, err)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status: got %d", tt.accept, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.accept) {
+			t.Errorf("%s: Content-Type: got %q", tt.accept, got)
+		}
+		if got := rec.Body.String(); got != tt.want {
+			t.Errorf("%s: body:\n got %q\nwant %q", tt.accept, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retryable is implemented by errors and Typers which know whether the
+// operation that failed with them may be retried.
+type Retryable interface {
+	Retryable() bool
+}
+
+// IsRetryable reports whether the operation that failed with err may be retried.
+//
+// The chain is walked from the outermost error and the first error that can
+// be classified decides:
+//   - errors and Typers implementing Retryable are classified by it,
+//   - errors with a Timeout() bool method returning true are retryable,
+//   - TypeLimitExceeded, TypeUnavailable and TypeTimeout are retryable,
+//     every other built-in type except TypeInternal is not,
+//   - other Typers are retryable if their HTTP status is 408, 429, 502, 503
+//     or 504, and not retryable for any other 4xx status.
+//
+// IsRetryable returns false if nothing in the chain can be classified. The
+// errors inside a boundary created by Opaque or Boundary are not classified.
+func IsRetryable(err error) bool {
+	for err != nil {
+		if retryable, ok := classifyRetry(err); ok {
+			return retryable
+		}
+		err = unwrapOutside(err)
+	}
+	return false
+}
+
+// classifyRetry classifies a single error, without looking at its cause.
+func classifyRetry(err error) (retryable, ok bool) {
+	if r, ok := err.(Retryable); ok {
+		return r.Retryable(), true
+	}
+	if t, ok := err.(interface {
+		Timeout() bool
+	}); ok && t.Timeout() {
+		return true, true
+	}
+	if err == context.DeadlineExceeded {
+		return true, true
+	}
+	if e, ok := err.(interface {
+		Type() Typer
+	}); ok {
+		return classifyType(e.Type())
+	}
+	return false, false
+}
+
+// classifyType classifies a Typer. TypeInternal is the default type of
+// errors of unknown nature, so it is left unclassified.
+func classifyType(et Typer) (retryable, ok bool) {
+	if r, ok := et.(Retryable); ok {
+		return r.Retryable(), true
+	}
+	if et == TypeInternal {
+		return false, false
+	}
+	switch code := et.HTTPStatusCode(); code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, true
+	default:
+		if code >= 400 && code < 500 {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// RetryPolicy configures Retry. Zero fields take their value from the Retry
+// policy of the Config of the default Factory.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of calls, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failed attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the computed backoff. It does not cap retry-after hints.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after each failed attempt.
+	Multiplier float64
+	// Jitter is the fraction, up to 1, of each backoff that is randomized.
+	// A negative Jitter disables the randomization.
+	Jitter float64
+}
+
+// defaultRetryPolicy fills in the zero fields of the Retry policy of a Config.
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// withDefaults returns p with its zero fields taken from d.
+func (p RetryPolicy) withDefaults(d RetryPolicy) RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = d.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = d.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = d.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = d.Multiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = d.Jitter
+	}
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	return p
+}
+
+// backoff returns the wait before the attempt following attempt n, counting from 1.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < n && d < float64(p.MaxBackoff); i++ {
+		d *= p.Multiplier
+	}
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d -= d * p.Jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// Retry calls fn until it succeeds, returns an error for which IsRetryable
+// is false, the policy's attempts are exhausted or ctx is done. Between
+// attempts it waits for an exponential backoff with jitter, or for the
+// hint returned by RetryAfter if the error carries one.
+//
+// Retry returns nil on success, and the error of fn unchanged if fn failed
+// once and was not retried. Otherwise the returned error holds the error of
+// every attempt, followed by the error of ctx if it ended the retries. Its
+// Cause and Unwrap return the last of them, while Is and As match any.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	return Default().retry(ctx, policy, fn)
+}
+
+// Retry is like the Retry function, using the Retry policy of f for the zero
+// fields of policy.
+func (f *Factory) Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	return f.retry(ctx, policy, fn)
+}
+
+func (f *Factory) retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	policy = policy.withDefaults(f.cfg.Retry)
+	var attempts []error
+retry:
+	for n := 1; ; n++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		attempts = append(attempts, err)
+		if n >= policy.MaxAttempts || !IsRetryable(err) {
+			break
+		}
+		wait := policy.backoff(n)
+		if hint, ok := RetryAfter(err); ok && hint > 0 {
+			wait = hint
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			attempts = append(attempts, ctx.Err())
+			break retry
+		case <-timer.C:
+		}
+	}
+	if len(attempts) == 1 {
+		return attempts[0]
+	}
+	var err error = &retryError{attempts, f.callers()}
+	f.created(ctx, err)
+	return err
+}
+
+// retryError is returned by Retry when all attempts failed.
+type retryError struct {
+	attempts []error
+	*stack
+}
+
+func (r *retryError) last() error { return r.attempts[len(r.attempts)-1] }
+
+func (r *retryError) Error() string {
+	return "after " + strconv.Itoa(len(r.attempts)) + " attempts: " + r.last().Error()
+}
+
+func (r *retryError) Cause() error { return r.last() }
+
+func (r *retryError) messageTemplate() (string, []interface{}) {
+	format, args := templateOf(r.last())
+	return "after %d attempts: " + format, append([]interface{}{len(r.attempts)}, args...)
+}
+
+// Unwrap provides compatibility for Go 1.13 error chains.
+func (r *retryError) Unwrap() error { return r.last() }
+
+// Is reports whether any attempt matches target, see Is.
+func (r *retryError) Is(target error) bool {
+	for _, err := range r.attempts {
+		if Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first attempt matching target, see As.
+func (r *retryError) As(target interface{}) bool {
+	for _, err := range r.attempts {
+		if As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *retryError) Type() Typer { return getErrType(r.last()) }
+
+func (r *retryError) APIError() (int, string) {
+	return r.Type().HTTPStatusCode(), r.Error()
+}
+
+func (r *retryError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, r.Error())
+			for i, err := range r.attempts {
+				fmt.Fprintf(s, "\nattempt %d: %+v", i+1, err)
+			}
+			r.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, r.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", r.Error())
+	}
+}
@@ -0,0 +1,177 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+type retryableError bool
+
+func (r retryableError) Error() string   { return "retryable" }
+func (r retryableError) Retryable() bool { return bool(r) }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, false},
+		{New("unknown"), false},
+		{LimitExceeded("slow down"), true},
+		{Wrap(LimitExceeded("slow down"), "call"), true},
+		{Validation("invalid"), false},
+		{Input("bad json"), false},
+		{NotFound("missing"), false},
+		{Wrap(timeoutError{}, "dial"), true},
+		{context.DeadlineExceeded, true},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), true},
+		{WrapType(io.EOF, NewCustomType("unavailable", http.StatusServiceUnavailable), "upstream"), true},
+		{WrapType(io.EOF, NewCustomType("conflict", http.StatusConflict), "upstream"), false},
+		{WrapType(timeoutError{}, TypeValidation, "deadline too short"), false},
+		{Wrap(retryableError(true), "x"), true},
+		{WithMessage(retryableError(false), "x"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v): got %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+var fastRetry = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     2 * time.Millisecond,
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), fastRetry, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return LimitExceeded("slow down")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Retry: got %v after %d calls, want nil after 3", err, calls)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	var errs []error
+	err := Retry(context.Background(), fastRetry, func(context.Context) error {
+		e := LimitExceededf("attempt %d", len(errs)+1)
+		errs = append(errs, e)
+		return e
+	})
+	if len(errs) != fastRetry.MaxAttempts {
+		t.Fatalf("Retry: got %d attempts, want %d", len(errs), fastRetry.MaxAttempts)
+	}
+	if got, want := err.Error(), "after 4 attempts: attempt 4"; got != want {
+		t.Errorf("Retry: got %q, want %q", got, want)
+	}
+	for _, e := range errs {
+		if !Is(err, e) {
+			t.Errorf("Retry: returned error does not wrap %v", e)
+		}
+	}
+	if Cause(err) != errs[len(errs)-1] || Unwrap(err) != errs[len(errs)-1] {
+		t.Errorf("Retry: Cause or Unwrap is not the last attempt")
+	}
+	var first *fundamental
+	if !As(err, &first) || first != errs[0] {
+		t.Errorf("Retry: As: got %v, want the first attempt", first)
+	}
+	if code, _ := GetAPIError(err); code != http.StatusTooManyRequests {
+		t.Errorf("Retry: got status %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	calls := 0
+	invalid := Validation("invalid")
+	err := Retry(context.Background(), fastRetry, func(context.Context) error {
+		calls++
+		return invalid
+	})
+	if calls != 1 || err != invalid {
+		t.Errorf("Retry: got %v after %d calls, want %v unchanged after 1", err, calls, invalid)
+	}
+}
+
+type retryAfterError time.Duration
+
+func (r retryAfterError) Error() string             { return "come back later" }
+func (r retryAfterError) Retryable() bool           { return true }
+func (r retryAfterError) RetryAfter() time.Duration { return time.Duration(r) }
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	start := time.Now()
+	calls := 0
+	Retry(context.Background(), RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}, func(context.Context) error {
+		calls++
+		return retryAfterError(30 * time.Millisecond)
+	})
+	if elapsed := time.Since(start); calls != 2 || elapsed < 30*time.Millisecond {
+		t.Errorf("Retry: %d calls in %v, want 2 calls in at least 30ms", calls, elapsed)
+	}
+}
+
+func TestRetryContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Retry(ctx, RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour}, func(context.Context) error {
+		cancel()
+		return LimitExceeded("slow down")
+	})
+	if Cause(err) != context.Canceled || !HasType(err.(*retryError).attempts[0], TypeLimitExceeded) {
+		t.Errorf("Retry: got %v, want attempt error followed by context.Canceled", err)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.5}.withDefaults(defaultRetryPolicy)
+	for n, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		d := p.backoff(n + 1)
+		if d > max || d < max/2 {
+			t.Errorf("backoff(%d): got %v, want between %v and %v", n+1, d, max/2, max)
+		}
+	}
+}
+
+func TestRetryPolicyNoJitter(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, Jitter: -1}.withDefaults(defaultRetryPolicy)
+	for i := 0; i < 10; i++ {
+		if d := p.backoff(2); d != 200*time.Millisecond {
+			t.Fatalf("backoff(2): got %v, want 200ms", d)
+		}
+	}
+}
+
+func TestRetryConfigPolicy(t *testing.T) {
+	defer SetDefault(Default())
+	SetDefault(NewFactory(Config{Retry: RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}}))
+
+	calls := 0
+	Retry(context.Background(), RetryPolicy{}, func(context.Context) error {
+		calls++
+		return LimitExceeded("slow down")
+	})
+	if calls != 2 {
+		t.Errorf("Retry: got %d calls, want 2", calls)
+	}
+	if p := Default().Config().Retry; p.Multiplier != 2 || p.Jitter != 0.2 {
+		t.Errorf("Config: got %+v", p)
+	}
+}
@@ -0,0 +1,214 @@
+package errors
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SentryEvent is an event of the Sentry protocol describing an error. It is
+// built by NewSentryEvent and encoded with encoding/json; fields such as
+// Release and Environment may be set before encoding.
+type SentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Exception   SentryExceptions       `json:"exception"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+}
+
+// SentryExceptions holds the exceptions of a SentryEvent, the root cause
+// first and the outermost error last.
+type SentryExceptions struct {
+	Values []SentryException `json:"values"`
+}
+
+// SentryException describes a single error of the chain.
+type SentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Module     string            `json:"module,omitempty"`
+	Mechanism  SentryMechanism   `json:"mechanism"`
+	Stacktrace *SentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+// SentryMechanism links the exceptions of a chain: every exception but the
+// outermost is the cause of the exception with the ID ParentID.
+type SentryMechanism struct {
+	Type        string `json:"type"`
+	Source      string `json:"source,omitempty"`
+	Handled     bool   `json:"handled"`
+	ExceptionID int    `json:"exception_id"`
+	ParentID    *int   `json:"parent_id,omitempty"`
+}
+
+// SentryStacktrace holds the frames of an exception, oldest first.
+type SentryStacktrace struct {
+	Frames []SentryFrame `json:"frames"`
+}
+
+// SentryFrame is a frame of a SentryStacktrace.
+type SentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+var sentryLevels = [...]string{
+	SeverityDebug:    "debug",
+	SeverityInfo:     "info",
+	SeverityWarn:     "warning",
+	SeverityError:    "error",
+	SeverityCritical: "fatal",
+}
+
+// NewSentryEvent builds the Sentry event describing err.
+//
+// Every error of err's chain with a message of its own becomes an exception,
+// so that wrappers only adding a stack trace, such as WithStack, are merged
+// with the error they wrap. The stack trace of an exception is the first one
+// recorded by the errors it was merged from. The level is derived from
+// GetSeverity, the tags from the type, HTTP status, message code and
+// incident ID of err, and the extra data from its Fields. The messages of
+// the exceptions and the extra data are scrubbed, see SetScrubbers.
+func NewSentryEvent(err error) *SentryEvent {
+	ev := &SentryEvent{
+		EventID:   newEventID(),
+		Timestamp: time.Now().UTC(),
+		Level:     sentryLevels[SeverityError],
+		Platform:  "go",
+	}
+	if err == nil {
+		return ev
+	}
+	if t, ok := Timestamp(err); ok {
+		ev.Timestamp = t.UTC()
+	}
+	if s := GetSeverity(err); s >= SeverityDebug && int(s) < len(sentryLevels) {
+		ev.Level = sentryLevels[s]
+	}
+	ev.Exception.Values = sentryExceptions(err)
+
+	code, _ := GetAPIError(err)
+	ev.Tags = map[string]string{
+		"error_type": typeName(getErrType(err)),
+		"status":     strconv.Itoa(code),
+	}
+	if c := messageCode(err); c != "" {
+		ev.Tags["code"] = c
+	}
+	if id := IncidentID(err); id != "" {
+		ev.Tags["incident_id"] = id
+	}
+	if fields := Fields(err); len(fields) > 0 {
+		ev.Extra = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			ev.Extra[f.Key] = scrubValue(f.Value)
+		}
+	}
+	return ev
+}
+
+// sentryExceptions returns the exceptions of err's chain, root cause first.
+func sentryExceptions(err error) []SentryException {
+	var excs []SentryException
+	for err != nil {
+		msg := Scrub(err.Error())
+		n := len(excs)
+		if n == 0 || excs[n-1].Value != msg {
+			exc := SentryException{
+				Value: msg,
+				Mechanism: SentryMechanism{
+					Type:        "generic",
+					Handled:     true,
+					ExceptionID: n,
+				},
+			}
+			if n > 0 {
+				parent := n - 1
+				exc.Mechanism.Type = "chained"
+				exc.Mechanism.Source = "cause"
+				exc.Mechanism.ParentID = &parent
+			}
+			excs = append(excs, exc)
+			n++
+		}
+		exc := &excs[n-1]
+		exc.Type = fmt.Sprintf("%T", err)
+		exc.Module = typePkgPath(err)
+		if st, ok := err.(interface {
+			StackTrace() StackTrace
+		}); ok && exc.Stacktrace == nil {
+			exc.Stacktrace = sentryStacktrace(st.StackTrace())
+		}
+		err = Unwrap(err)
+	}
+	for i, j := 0, len(excs)-1; i < j; i, j = i+1, j-1 {
+		excs[i], excs[j] = excs[j], excs[i]
+	}
+	return excs
+}
+
+// sentryStacktrace converts st, innermost frame first, into Sentry frames,
+// oldest first.
+func sentryStacktrace(st StackTrace) *SentryStacktrace {
+	if len(st) == 0 {
+		return nil
+	}
+	prefixes := getPathOptions().Prefixes
+	frames := make([]SentryFrame, len(st))
+	for i, f := range st {
+		sym := f.symbol()
+		frames[len(st)-1-i] = SentryFrame{
+			Function: funcname(sym.name),
+			Module:   pkgpath(sym.name),
+			Filename: trimPath(sym.file, prefixes),
+			AbsPath:  sym.file,
+			Lineno:   sym.line,
+			InApp:    inApp(sym.file),
+		}
+	}
+	return &SentryStacktrace{Frames: frames}
+}
+
+// inApp reports whether file belongs to the application, rather than to the
+// standard library or to a dependency.
+func inApp(file string) bool {
+	if file == "unknown" || strings.Contains(file, modCacheMarker) || strings.Contains(file, "/vendor/") {
+		return false
+	}
+	if root := runtime.GOROOT(); root != "" {
+		return !strings.HasPrefix(file, filepath.ToSlash(filepath.Join(root, "src"))+"/")
+	}
+	return true
+}
+
+// typePkgPath returns the import path of the package declaring the type of err.
+func typePkgPath(err error) string {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath()
+}
+
+// newEventID returns a random event ID of 32 hexadecimal digits.
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,127 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+func TestNewSentryEvent(t *testing.T) {
+	err := Wrap(WithStack(io.EOF), "read config")
+	ev := NewSentryEvent(err)
+
+	if !regexp.MustCompile("^[0-9a-f]{32}$").MatchString(ev.EventID) {
+		t.Errorf("event_id: got %q", ev.EventID)
+	}
+	if ev.Level != "error" || ev.Platform != "go" {
+		t.Errorf("level, platform: got %q, %q", ev.Level, ev.Platform)
+	}
+	if ev.Tags["error_type"] != "internal" || ev.Tags["status"] != "500" {
+		t.Errorf("tags: got %v", ev.Tags)
+	}
+
+	excs := ev.Exception.Values
+	if len(excs) != 2 {
+		t.Fatalf("exceptions: got %d, want 2", len(excs))
+	}
+	root, outer := excs[0], excs[1]
+	if root.Type != "*errors.errorString" || root.Value != "EOF" || root.Module != "errors" {
+		t.Errorf("root: got %+v", root)
+	}
+	if outer.Type != "*errors.withMessage" || outer.Value != "read config: EOF" || outer.Module != "github.com/bynil/errors" {
+		t.Errorf("outer: got %+v", outer)
+	}
+	if m := outer.Mechanism; m.Type != "generic" || m.ExceptionID != 0 || m.ParentID != nil || !m.Handled {
+		t.Errorf("outer mechanism: got %+v", m)
+	}
+	if m := root.Mechanism; m.Type != "chained" || m.Source != "cause" || m.ExceptionID != 1 || m.ParentID == nil || *m.ParentID != 0 {
+		t.Errorf("root mechanism: got %+v", m)
+	}
+
+	for _, exc := range excs {
+		if exc.Stacktrace == nil {
+			t.Fatalf("%s: no stacktrace", exc.Type)
+		}
+		frames := exc.Stacktrace.Frames
+		last := frames[len(frames)-1]
+		if last.Function != "TestNewSentryEvent" || last.Module != "github.com/bynil/errors" || !last.InApp ||
+			!regexp.MustCompile("/sentry_test.go$").MatchString(last.AbsPath) || last.Lineno != 14 {
+			t.Errorf("%s: newest frame: got %+v", exc.Type, last)
+		}
+		if first := frames[0]; first.InApp {
+			t.Errorf("%s: oldest frame: got %+v, want not in app", exc.Type, first)
+		}
+	}
+}
+
+func TestNewSentryEventTagsAndExtra(t *testing.T) {
+	ctx := withExtractors(t)
+	err := WrapCtx(ctx, NewI18n(TypeNotFound, &i18n.LocalizeConfig{MessageID: "user_not_found"}), "load")
+	ev := NewSentryEvent(WithSeverity(err, SeverityWarn))
+
+	if ev.Level != "warning" {
+		t.Errorf("level: got %q", ev.Level)
+	}
+	if ev.Tags["error_type"] != "not_found" || ev.Tags["status"] != "404" || ev.Tags["code"] != "user_not_found" {
+		t.Errorf("tags: got %v", ev.Tags)
+	}
+	if ev.Extra["request_id"] != "req-1" || ev.Extra["user_id"] != 42 {
+		t.Errorf("extra: got %v", ev.Extra)
+	}
+}
+
+func TestNewSentryEventJSON(t *testing.T) {
+	b, err := json.Marshal(NewSentryEvent(NotFoundCtx(context.Background(), "no such user")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Timestamp string
+		Exception struct {
+			Values []struct {
+				Type       string
+				Value      string
+				Stacktrace struct {
+					Frames []struct {
+						Function string
+						Filename string
+						Lineno   int
+						InApp    bool `json:"in_app"`
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Timestamp == "" || len(got.Exception.Values) != 1 || got.Exception.Values[0].Value != "no such user" {
+		t.Fatalf("json.Marshal: got %s", b)
+	}
+	frames := got.Exception.Values[0].Stacktrace.Frames
+	if len(frames) == 0 || frames[len(frames)-1].Function != "TestNewSentryEventJSON" {
+		t.Errorf("frames: got %+v", frames)
+	}
+}
+
+func TestNewSentryEventScrubbed(t *testing.T) {
+	defer SetScrubbers(getScrubbers()...)
+	SetScrubbers(DefaultScrubbers()...)
+
+	ctx := context.WithValue(context.Background(), sentryEmailKey{}, "jane@example.com")
+	defer RegisterExtractor("email", ContextValue(sentryEmailKey{}))()
+	ev := NewSentryEvent(Wrap(NewCtx(ctx, "no user jane@example.com"), "login"))
+	b, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regexp.MustCompile(`jane@example\.com`).Match(b) {
+		t.Errorf("NewSentryEvent: unscrubbed data in %s", b)
+	}
+}
+
+type sentryEmailKey struct{}
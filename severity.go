@@ -0,0 +1,88 @@
+package errors
+
+import (
+	"context"
+	"strconv"
+)
+
+// Severity classifies errors for logging and alerting.
+type Severity int
+
+const (
+	// SeverityDebug is for errors only worth logging while debugging.
+	SeverityDebug Severity = iota
+	// SeverityInfo is for expected errors, e.g. client errors with a 4xx status.
+	SeverityInfo
+	// SeverityWarn is for unexpected errors which do not need attention.
+	SeverityWarn
+	// SeverityError is for bugs and failures, e.g. errors with a 5xx status.
+	SeverityError
+	// SeverityCritical is for catastrophic failures which should page someone.
+	SeverityCritical
+)
+
+var severityNames = [...]string{
+	SeverityDebug:    "debug",
+	SeverityInfo:     "info",
+	SeverityWarn:     "warn",
+	SeverityError:    "error",
+	SeverityCritical: "critical",
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return "Severity(" + strconv.Itoa(int(s)) + ")"
+	}
+	return severityNames[s]
+}
+
+// WithSeverity annotates err with the severity s, overriding the severity
+// derived from its type. If err is nil, WithSeverity returns nil.
+func WithSeverity(err error, s Severity) error {
+	if err == nil {
+		return nil
+	}
+	err = withMetadata(err, severity{s})
+	Default().created(context.Background(), err)
+	return err
+}
+
+// severity is the metadata added by WithSeverity.
+type severity struct {
+	s Severity
+}
+
+func (s severity) Severity() Severity { return s.s }
+
+// GetSeverity returns the severity of the outermost error in err's chain
+// outside any boundary which has one, as set by WithSeverity or by any error
+// implementing
+//
+//	interface {
+//		Severity() Severity
+//	}
+//
+// Otherwise the severity is derived from the HTTP status of err's type:
+// SeverityError for 5xx statuses and SeverityInfo for any other.
+// GetSeverity returns SeverityDebug if err is nil.
+func GetSeverity(err error) Severity {
+	if err == nil {
+		return SeverityDebug
+	}
+	for e := err; e != nil; e = unwrapOutside(e) {
+		if s, ok := metaOf(e).(interface {
+			Severity() Severity
+		}); ok {
+			return s.Severity()
+		}
+	}
+	return severityOf(getErrType(err))
+}
+
+// severityOf returns the severity derived from the HTTP status of et.
+func severityOf(et Typer) Severity {
+	if et.HTTPStatusCode() >= 500 {
+		return SeverityError
+	}
+	return SeverityInfo
+}
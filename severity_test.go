@@ -0,0 +1,45 @@
+package errors
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGetSeverity(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Severity
+	}{
+		{nil, SeverityDebug},
+		{io.EOF, SeverityError},
+		{New("boom"), SeverityError},
+		{NotFound("missing"), SeverityInfo},
+		{Wrap(Validation("invalid"), "signup"), SeverityInfo},
+		{Unavailable("db down"), SeverityError},
+		{WrapType(io.EOF, NewCustomType("teapot", http.StatusTeapot), "brew"), SeverityInfo},
+		{WithSeverity(Internal("disk full"), SeverityCritical), SeverityCritical},
+		{Wrap(WithSeverity(NotFound("missing"), SeverityWarn), "lookup"), SeverityWarn},
+		{WithSeverity(WithSeverity(io.EOF, SeverityWarn), SeverityDebug), SeverityDebug},
+	}
+	for _, tt := range tests {
+		if got := GetSeverity(tt.err); got != tt.want {
+			t.Errorf("GetSeverity(%v): got %v, want %v", tt.err, got, tt.want)
+		}
+	}
+	if WithSeverity(nil, SeverityCritical) != nil {
+		t.Errorf("WithSeverity(nil): got non-nil error")
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	for s, want := range map[Severity]string{
+		SeverityDebug:    "debug",
+		SeverityCritical: "critical",
+		Severity(42):     "Severity(42)",
+	} {
+		if got := s.String(); got != want {
+			t.Errorf("Severity(%d).String(): got %q, want %q", int(s), got, want)
+		}
+	}
+}
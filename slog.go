@@ -0,0 +1,84 @@
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+import (
+	"log/slog"
+)
+
+// logValue returns the structured representation of err used by log/slog.
+// The message template and its arguments are logged separately from the
+// formatted message, so that log aggregation can group by template. The
+// message, arguments and fields are scrubbed.
+func logValue(err error) slog.Value {
+	format, args := templateOf(err)
+	attrs := []slog.Attr{
+		slog.String("msg", Scrub(err.Error())),
+		slog.String("template", format),
+	}
+	if len(args) > 0 {
+		scrubbed := make([]interface{}, len(args))
+		for i, arg := range args {
+			scrubbed[i] = scrubValue(arg)
+		}
+		attrs = append(attrs, slog.Any("args", scrubbed))
+	}
+	if fields := Fields(err); len(fields) > 0 {
+		group := make([]slog.Attr, len(fields))
+		for i, f := range fields {
+			group[i] = slog.Any(f.Key, scrubValue(f.Value))
+		}
+		attrs = append(attrs, slog.Attr{Key: "fields", Value: slog.GroupValue(group...)})
+	}
+	if id := IncidentID(err); id != "" {
+		attrs = append(attrs, slog.String("incident_id", id))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer.
+func (f *fundamental) LogValue() slog.Value { return logValue(f) }
+
+// LogValue implements slog.LogValuer.
+func (w *withStack) LogValue() slog.Value { return logValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (w *withMessage) LogValue() slog.Value { return logValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (l *localization) LogValue() slog.Value { return logValue(l) }
+
+// LogValue implements slog.LogValuer.
+func (w *withMeta) LogValue() slog.Value { return logValue(w) }
+
+// LogValue implements slog.LogValuer.
+func (b *boundary) LogValue() slog.Value { return logValue(b) }
+
+// LogValue implements slog.LogValuer.
+func (r *retryError) LogValue() slog.Value { return logValue(r) }
+
+// LogValue implements slog.LogValuer.
+func (c *ClientError) LogValue() slog.Value { return logValue(c) }
+
+// LogValue implements slog.LogValuer.
+func (w *withType) LogValue() slog.Value { return logValue(w) }
+
+// LogValue implements slog.LogValuer, it logs [REDACTED].
+func (s Secret) LogValue() slog.Value { return slog.StringValue(RedactedText) }
+
+// Level returns the slog.Level for s. SeverityCritical maps to a level
+// above slog.LevelError.
+func (s Severity) Level() slog.Level {
+	switch {
+	case s <= SeverityDebug:
+		return slog.LevelDebug
+	case s == SeverityInfo:
+		return slog.LevelInfo
+	case s == SeverityWarn:
+		return slog.LevelWarn
+	case s == SeverityError:
+		return slog.LevelError
+	}
+	return slog.LevelError + 4
+}
@@ -0,0 +1,103 @@
+//go:build go1.21
+// +build go1.21
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Error("failed", "err", Wrapf(NotFoundf("user %d not found", 42), "load %s", "profile"))
+
+	var got struct {
+		Err struct {
+			Msg      string        `json:"msg"`
+			Template string        `json:"template"`
+			Args     []interface{} `json:"args"`
+		} `json:"err"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Err.Msg != "load profile: user 42 not found" {
+		t.Errorf("msg: got %q", got.Err.Msg)
+	}
+	if got.Err.Template != "load %s: user %d not found" {
+		t.Errorf("template: got %q", got.Err.Template)
+	}
+	if len(got.Err.Args) != 2 {
+		t.Errorf("args: got %v", got.Err.Args)
+	}
+}
+
+func TestWrapperLogValue(t *testing.T) {
+	for name, err := range wrappers(Errorf("user %d not found", 42)) {
+		v, ok := err.(slog.LogValuer)
+		if !ok {
+			t.Errorf("%s: does not implement slog.LogValuer", name)
+			continue
+		}
+		attrs := v.LogValue().Group()
+		if len(attrs) < 2 || attrs[0].Value.String() != err.Error() || attrs[1].Value.String() != Template(err) {
+			t.Errorf("%s: LogValue: got %v", name, attrs)
+		}
+	}
+}
+
+func TestSeverityLevel(t *testing.T) {
+	tests := []struct {
+		s    Severity
+		want slog.Level
+	}{
+		{SeverityDebug, slog.LevelDebug},
+		{SeverityInfo, slog.LevelInfo},
+		{SeverityWarn, slog.LevelWarn},
+		{SeverityError, slog.LevelError},
+		{SeverityCritical, slog.LevelError + 4},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Level(); got != tt.want {
+			t.Errorf("%v.Level(): got %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestLogValueFields(t *testing.T) {
+	ctx := withExtractors(t)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Error("failed", "err", WrapCtx(ctx, NotFound("user not found"), "load profile"))
+
+	var got struct {
+		Err struct {
+			Fields map[string]interface{} `json:"fields"`
+		} `json:"err"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Err.Fields["request_id"] != "req-1" || got.Err.Fields["user_id"] != 42.0 {
+		t.Errorf("fields: got %v", got.Err.Fields)
+	}
+}
+
+func TestLogValueScrubbed(t *testing.T) {
+	defer SetScrubbers(getScrubbers()...)
+	SetScrubbers(DefaultScrubbers()...)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Error("failed", "err", Wrapf(Unauthenticatedf("bad token %s", Redacted("t-123")), "login %s", "jane@example.com"), "token", Redacted("t-456"))
+
+	for _, secret := range []string{"t-123", "t-456", "jane@example.com"} {
+		if bytes.Contains(buf.Bytes(), []byte(secret)) {
+			t.Errorf("log output contains %q: %s", secret, buf.Bytes())
+		}
+	}
+}
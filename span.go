@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"fmt"
+)
+
+// Attribute names of exception events, from the OpenTelemetry semantic
+// conventions.
+const (
+	AttrExceptionType       = "exception.type"
+	AttrExceptionMessage    = "exception.message"
+	AttrExceptionStacktrace = "exception.stacktrace"
+)
+
+// SpanStatusCode is the status of a span. The values match the codes of
+// go.opentelemetry.io/otel/codes.
+type SpanStatusCode uint32
+
+const (
+	SpanStatusUnset SpanStatusCode = iota
+	SpanStatusError
+	SpanStatusOK
+)
+
+// SpanAttribute is a string attribute of a span event.
+type SpanAttribute struct {
+	Key   string
+	Value string
+}
+
+// Span is the subset of a tracing span used by RecordOnSpan. An
+// OpenTelemetry span is adapted with a few lines, e.g.
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) AddEvent(name string, attrs ...errors.SpanAttribute) {
+//		kvs := make([]attribute.KeyValue, len(attrs))
+//		for i, a := range attrs {
+//			kvs[i] = attribute.String(a.Key, a.Value)
+//		}
+//		s.Span.AddEvent(name, trace.WithAttributes(kvs...))
+//	}
+//
+//	func (s otelSpan) SetStatus(code errors.SpanStatusCode, description string) {
+//		s.Span.SetStatus(codes.Code(code), description)
+//	}
+type Span interface {
+	AddEvent(name string, attrs ...SpanAttribute)
+	SetStatus(code SpanStatusCode, description string)
+}
+
+// RecordOnSpan records err on span as an exception event, with the type,
+// message and stack trace of err as attributes. The message is the one
+// returned by GetAPIError, which is also the description of the status, and
+// the stack trace is err formatted with %+v; both are scrubbed. The
+// exception type is the name of err's type, e.g. not_found, or the Go type
+// of its cause if no error in the chain has a type. If the HTTP status of err is 5xx, the status of
+// span is also set to SpanStatusError. If err is nil, RecordOnSpan does
+// nothing.
+func RecordOnSpan(span Span, err error) {
+	if err == nil {
+		return
+	}
+	code, msg := GetAPIError(err)
+	span.AddEvent("exception",
+		SpanAttribute{AttrExceptionType, exceptionType(err)},
+		SpanAttribute{AttrExceptionMessage, msg},
+		SpanAttribute{AttrExceptionStacktrace, Scrub(fmt.Sprintf("%+v", err))},
+	)
+	if code >= 500 {
+		span.SetStatus(SpanStatusError, msg)
+	}
+}
+
+// exceptionType returns the name of err's type, or the Go type of its cause.
+func exceptionType(err error) string {
+	if hasTyper(err) {
+		return typeName(getErrType(err))
+	}
+	return fmt.Sprintf("%T", Cause(err))
+}
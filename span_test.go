@@ -0,0 +1,100 @@
+package errors
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+type spanEvent struct {
+	name  string
+	attrs map[string]string
+}
+
+// fakeSpan records the events and status set on it.
+type fakeSpan struct {
+	events []spanEvent
+	code   SpanStatusCode
+	desc   string
+}
+
+func (s *fakeSpan) AddEvent(name string, attrs ...SpanAttribute) {
+	e := spanEvent{name, make(map[string]string)}
+	for _, a := range attrs {
+		e.attrs[a.Key] = a.Value
+	}
+	s.events = append(s.events, e)
+}
+
+func (s *fakeSpan) SetStatus(code SpanStatusCode, description string) {
+	s.code, s.desc = code, description
+}
+
+func TestRecordOnSpan(t *testing.T) {
+	tests := []struct {
+		err      error
+		wantType string
+		wantMsg  string
+		wantCode SpanStatusCode
+	}{
+		{NotFound("no such user"), "not_found", "no such user", SpanStatusUnset},
+		{Wrap(Unavailable("db down"), "load user"), "unavailable", "load user: db down", SpanStatusError},
+		{Wrap(io.EOF, "read"), "internal", "read: EOF", SpanStatusError},
+		{io.EOF, "*errors.errorString", "EOF", SpanStatusError},
+	}
+	for i, tt := range tests {
+		span := new(fakeSpan)
+		RecordOnSpan(span, tt.err)
+		if len(span.events) != 1 || span.events[0].name != "exception" {
+			t.Fatalf("test %d: events: got %v", i+1, span.events)
+		}
+		attrs := span.events[0].attrs
+		if got := attrs[AttrExceptionType]; got != tt.wantType {
+			t.Errorf("test %d: %s: got %q, want %q", i+1, AttrExceptionType, got, tt.wantType)
+		}
+		if got := attrs[AttrExceptionMessage]; got != tt.wantMsg {
+			t.Errorf("test %d: %s: got %q, want %q", i+1, AttrExceptionMessage, got, tt.wantMsg)
+		}
+		if got := attrs[AttrExceptionStacktrace]; !strings.HasPrefix(got, Cause(tt.err).Error()) {
+			t.Errorf("test %d: %s: got %q", i+1, AttrExceptionStacktrace, got)
+		}
+		if span.code != tt.wantCode {
+			t.Errorf("test %d: status: got %v, want %v", i+1, span.code, tt.wantCode)
+		}
+	}
+}
+
+func TestRecordOnSpanStacktrace(t *testing.T) {
+	span := new(fakeSpan)
+	RecordOnSpan(span, New("boom"))
+	if got := span.events[0].attrs[AttrExceptionStacktrace]; !strings.Contains(got, "TestRecordOnSpanStacktrace") {
+		t.Errorf("%s: got %q", AttrExceptionStacktrace, got)
+	}
+	if span.code != SpanStatusError || span.desc != "boom" {
+		t.Errorf("status: got %v %q", span.code, span.desc)
+	}
+}
+
+func TestRecordOnSpanScrubbed(t *testing.T) {
+	defer SetScrubbers(getScrubbers()...)
+	SetScrubbers(DefaultScrubbers()...)
+
+	span := new(fakeSpan)
+	RecordOnSpan(span, Unavailable("cannot mail jane@example.com"))
+	msg := span.events[0].attrs[AttrExceptionMessage]
+	if strings.Contains(msg, "jane@example.com") || msg != span.desc {
+		t.Errorf("%s: got %q and status %q, want the same scrubbed message", AttrExceptionMessage, msg, span.desc)
+	}
+	st := span.events[0].attrs[AttrExceptionStacktrace]
+	if strings.Contains(st, "jane@example.com") || !strings.Contains(st, "TestRecordOnSpanScrubbed") {
+		t.Errorf("%s: got %q, want a scrubbed stack trace", AttrExceptionStacktrace, st)
+	}
+}
+
+func TestRecordOnSpanNil(t *testing.T) {
+	span := new(fakeSpan)
+	RecordOnSpan(span, nil)
+	if len(span.events) != 0 || span.code != SpanStatusUnset {
+		t.Errorf("RecordOnSpan(nil): got %v", span)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // Frame represents a program counter inside a stack frame.
@@ -18,36 +19,46 @@ type Frame uintptr
 // multiple frames may have the same PC value.
 func (f Frame) pc() uintptr { return uintptr(f) - 1 }
 
-// file returns the full path to the file that contains the
-// function for this Frame's pc.
-func (f Frame) file() string {
-	fn := runtime.FuncForPC(f.pc())
+// symbol holds the resolved function name, file and line for a program counter.
+type symbol struct {
+	name string
+	file string
+	line int
+}
+
+var unknownSymbol = &symbol{name: "unknown", file: "unknown"}
+
+// symbols caches resolved symbols keyed by program counter. The set of
+// program counters in a binary is bounded, so the cache never needs eviction.
+var symbols sync.Map // map[uintptr]*symbol
+
+// symbol returns the resolved symbol for this Frame's pc, consulting
+// runtime.FuncForPC at most once per distinct pc.
+func (f Frame) symbol() *symbol {
+	pc := f.pc()
+	if v, ok := symbols.Load(pc); ok {
+		return v.(*symbol)
+	}
+	fn := runtime.FuncForPC(pc)
 	if fn == nil {
-		return "unknown"
+		return unknownSymbol
 	}
-	file, _ := fn.FileLine(f.pc())
-	return file
+	sym := &symbol{name: fn.Name()}
+	sym.file, sym.line = fn.FileLine(pc)
+	v, _ := symbols.LoadOrStore(pc, sym)
+	return v.(*symbol)
 }
 
+// file returns the full path to the file that contains the
+// function for this Frame's pc.
+func (f Frame) file() string { return f.symbol().file }
+
 // line returns the line number of source code of the
 // function for this Frame's pc.
-func (f Frame) line() int {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return 0
-	}
-	_, line := fn.FileLine(f.pc())
-	return line
-}
+func (f Frame) line() int { return f.symbol().line }
 
 // name returns the name of this function, if known.
-func (f Frame) name() string {
-	fn := runtime.FuncForPC(f.pc())
-	if fn == nil {
-		return "unknown"
-	}
-	return fn.Name()
-}
+func (f Frame) name() string { return f.symbol().name }
 
 // Format formats the frame according to the fmt.Formatter interface.
 //
@@ -58,39 +69,47 @@ func (f Frame) name() string {
 //
 // Format accepts flags that alter the printing of some verbs, as follows:
 //
-//    %+s   function name and path of source file relative to the compile time
-//          GOPATH separated by \n\t (<funcname>\n\t<path>)
+//    %+s   function name and path of source file separated by \n\t
+//          (<funcname>\n\t<path>), the path is rewritten as configured
+//          by SetPathOptions
 //    %+v   equivalent to %+s:%d
 func (f Frame) Format(s fmt.State, verb rune) {
+	sym := f.symbol()
 	switch verb {
 	case 's':
-		switch {
-		case s.Flag('+'):
-			io.WriteString(s, f.name())
-			io.WriteString(s, "\n\t")
-			io.WriteString(s, f.file())
-		default:
-			io.WriteString(s, path.Base(f.file()))
-		}
+		sym.formatFile(s)
 	case 'd':
-		io.WriteString(s, strconv.Itoa(f.line()))
+		io.WriteString(s, strconv.Itoa(sym.line))
 	case 'n':
-		io.WriteString(s, funcname(f.name()))
+		io.WriteString(s, funcname(sym.name))
 	case 'v':
-		f.Format(s, 's')
+		sym.formatFile(s)
 		io.WriteString(s, ":")
-		f.Format(s, 'd')
+		io.WriteString(s, strconv.Itoa(sym.line))
+	}
+}
+
+// formatFile writes the source file of sym, prefixed with the function
+// name when the '+' flag is set.
+func (sym *symbol) formatFile(s fmt.State) {
+	switch {
+	case s.Flag('+'):
+		io.WriteString(s, sym.name)
+		io.WriteString(s, "\n\t")
+		io.WriteString(s, rewritePath(sym.file, sym.name))
+	default:
+		io.WriteString(s, path.Base(sym.file))
 	}
 }
 
 // MarshalText formats a stacktrace Frame as a text string. The output is the
 // same as that of fmt.Sprintf("%+v", f), but without newlines or tabs.
 func (f Frame) MarshalText() ([]byte, error) {
-	name := f.name()
-	if name == "unknown" {
-		return []byte(name), nil
+	sym := f.symbol()
+	if sym == unknownSymbol {
+		return []byte(sym.name), nil
 	}
-	return []byte(fmt.Sprintf("%s %s:%d", name, f.file(), f.line())), nil
+	return []byte(fmt.Sprintf("%s %s:%d", sym.name, rewritePath(sym.file, sym.name), sym.line)), nil
 }
 
 // StackTrace is stack of Frames from innermost (newest) to outermost (oldest).
@@ -136,15 +155,20 @@ func (st StackTrace) formatSlice(s fmt.State, verb rune) {
 	io.WriteString(s, "]")
 }
 
-// stack represents a stack of program counters.
-type stack []uintptr
+// stack represents a stack of program counters. Its StackTrace is built
+// once, on first use.
+type stack struct {
+	pcs   []uintptr
+	once  sync.Once
+	trace StackTrace
+}
 
 func (s *stack) Format(st fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		switch {
 		case st.Flag('+'):
-			for _, pc := range *s {
+			for _, pc := range s.pcs {
 				f := Frame(pc)
 				fmt.Fprintf(st, "\n%+v", f)
 			}
@@ -152,20 +176,37 @@ func (s *stack) Format(st fmt.State, verb rune) {
 	}
 }
 
+// StackTrace returns the recorded program counters as a StackTrace. It is
+// built on the first call and shared by every later one, so the caller must
+// not modify it. Modifying it does not change how the error is formatted.
 func (s *stack) StackTrace() StackTrace {
-	f := make([]Frame, len(*s))
-	for i := 0; i < len(f); i++ {
-		f[i] = Frame((*s)[i])
+	s.once.Do(func() {
+		s.trace = make(StackTrace, len(s.pcs))
+		for i, pc := range s.pcs {
+			s.trace[i] = Frame(pc)
+		}
+	})
+	return s.trace
+}
+
+// stackOf returns the innermost stack trace recorded in err's chain, or nil.
+func stackOf(err error) StackTrace {
+	var st StackTrace
+	for err != nil {
+		if tracer, ok := err.(interface {
+			StackTrace() StackTrace
+		}); ok {
+			st = tracer.StackTrace()
+		}
+		err = Unwrap(err)
 	}
-	return f
+	return st
 }
 
+// callers records the stack trace of the caller of the function calling
+// callers, as configured for the default Factory.
 func callers() *stack {
-	const depth = 32
-	var pcs [depth]uintptr
-	n := runtime.Callers(3, pcs[:])
-	var st stack = pcs[0:n]
-	return &st
+	return captureStack(4, Default().cfg.Stack.Depth)
 }
 
 // funcname removes the path prefix component of a function's name reported by func.Name().
@@ -172,7 +172,7 @@ func stackTrace() StackTrace {
 	const depth = 8
 	var pcs [depth]uintptr
 	n := runtime.Callers(1, pcs[:])
-	var st stack = pcs[0:n]
+	st := stack{pcs: pcs[0:n]}
 	return st.StackTrace()
 }
 
@@ -248,3 +248,15 @@ func caller() Frame {
 	frame, _ := frames.Next()
 	return Frame(frame.PC)
 }
+
+func TestStackTraceCopy(t *testing.T) {
+	err := New("x").(*fundamental)
+	st := err.StackTrace()
+	want := fmt.Sprintf("%+v", err)
+	for i := range st {
+		st[i] = 0
+	}
+	if got := fmt.Sprintf("%+v", err); got != want {
+		t.Errorf("modifying the StackTrace changed the error: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package errors
+
+import "strings"
+
+// template records the format specifier and arguments a message was built
+// from, so that messages differing only in their arguments can be grouped.
+type template struct {
+	format string
+	args   []interface{}
+}
+
+// resolve returns the format and arguments for msg, falling back to msg
+// itself, escaped for use as a format specifier, if no template was recorded.
+func (t template) resolve(msg string) (string, []interface{}) {
+	if t.format == "" && t.args == nil {
+		return escapeFormat(msg), nil
+	}
+	return t.format, t.args
+}
+
+// escapeFormat escapes msg so that it formats to itself.
+func escapeFormat(msg string) string {
+	return strings.Replace(msg, "%", "%%", -1)
+}
+
+// Template returns the format specifier err's message was built from. For
+// every error in the chain created by Errorf, Wrapf, WithMessagef or one of
+// the formatting type helpers, the format is used in place of the formatted
+// message, so that
+//
+//	fmt.Sprintf(errors.Template(err), errors.Args(err)...) == err.Error()
+//
+// Messages of errors without a template are escaped and included verbatim.
+// Template returns the empty string if err is nil.
+func Template(err error) string {
+	format, _ := templateOf(err)
+	return format
+}
+
+// Args returns the arguments to Template(err), in order.
+func Args(err error) []interface{} {
+	_, args := templateOf(err)
+	return args
+}
+
+// templater is implemented by the errors which know the template their
+// message was built from.
+type templater interface {
+	messageTemplate() (format string, args []interface{})
+}
+
+func templateOf(err error) (string, []interface{}) {
+	switch err := err.(type) {
+	case nil:
+		return "", nil
+	case templater:
+		return err.messageTemplate()
+	default:
+		return escapeFormat(err.Error()), nil
+	}
+}
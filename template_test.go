@@ -0,0 +1,94 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		err      error
+		template string
+		args     []interface{}
+	}{
+		{nil, "", nil},
+		{io.EOF, "EOF", nil},
+		{New("100% done"), "100%% done", nil},
+		{Errorf("user %d not found", 42), "user %d not found", []interface{}{42}},
+		{NotFoundf("user %d not found", 42), "user %d not found", []interface{}{42}},
+		{Wrap(Errorf("user %d not found", 42), "load"), "load: user %d not found", []interface{}{42}},
+		{Wrapf(io.EOF, "read %s", "a.txt"), "read %s: EOF", []interface{}{"a.txt"}},
+		{
+			WithMessagef(WrapTypef(Errorf("%s", "a"), TypeInput, "%s-%d", "b", 1), "%s", "c"),
+			"%s: %s-%d: %s",
+			[]interface{}{"c", "b", 1, "a"},
+		},
+		{WithStack(Inputf("bad %q", "x")), "bad %q", []interface{}{"x"}},
+	}
+	for _, tt := range tests {
+		if got := Template(tt.err); got != tt.template {
+			t.Errorf("Template(%v): got %q, want %q", tt.err, got, tt.template)
+		}
+		got := Args(tt.err)
+		if !reflect.DeepEqual(got, tt.args) {
+			t.Errorf("Args(%v): got %#v, want %#v", tt.err, got, tt.args)
+		}
+		if tt.err != nil && fmt.Sprintf(Template(tt.err), got...) != tt.err.Error() {
+			t.Errorf("Sprintf(Template, Args...) = %q, want %q", fmt.Sprintf(Template(tt.err), got...), tt.err.Error())
+		}
+	}
+}
+
+// wrappers returns err wrapped by each of the wrapper types of the package,
+// keyed by the name of the type.
+func wrappers(err error) map[string]error {
+	req, _ := http.NewRequest("GET", "http://example.com/users/42", nil)
+	ctx := ContextWithIncidentID(context.Background(), "inc-1")
+	return map[string]error{
+		"withStack":    WithStack(err),
+		"withMessage":  WithMessage(err, "load"),
+		"withOrigin":   WithOrigin(err),
+		"withFields":   WrapCtx(context.Background(), err, "load"),
+		"withIncident": WithIncidentID(err, "inc-1"),
+		"rateLimited":  withRateLimit(RateLimit{RetryAfter: time.Second})(err),
+		"challenged":   WithChallenge(err, Challenge{Scheme: "Bearer"}),
+		"withHeader":   WithHeader(err, "X-Reason", "test"),
+		"withSeverity": WithSeverity(err, SeverityWarn),
+		"boundary":     Boundary(err),
+		"retryError":   &retryError{[]error{io.EOF, err}, callers()},
+		"ClientError":  FromHTTP(req, nil, err),
+		"attached":     AttachIncidentID(ctx, err),
+	}
+}
+
+func TestTemplateWrappers(t *testing.T) {
+	for name, err := range wrappers(Errorf("user %d not found", 42)) {
+		template, args := Template(err), Args(err)
+		if len(args) == 0 || args[len(args)-1] != 42 {
+			t.Errorf("%s: Args: got %#v, want the arguments of the wrapped error", name, args)
+		}
+		if got := fmt.Sprintf(template, args...); got != err.Error() {
+			t.Errorf("%s: Sprintf(Template, Args...) = %q, want %q", name, got, err.Error())
+		}
+	}
+}
+
+func TestFingerprintTemplate(t *testing.T) {
+	opts := FingerprintOptions{IncludeTemplate: true, IgnoreLines: true}
+	var errs []error
+	for _, id := range []int{42, 43} {
+		errs = append(errs, Errorf("user %d not found", id))
+	}
+	errs = append(errs, Errorf("user %d gone", 42))
+	if opts.Fingerprint(errs[0]) != opts.Fingerprint(errs[1]) {
+		t.Errorf("Fingerprint differs for the same template")
+	}
+	if opts.Fingerprint(errs[0]) == opts.Fingerprint(errs[2]) {
+		t.Errorf("Fingerprint equal for different templates")
+	}
+}
@@ -0,0 +1,184 @@
+package errors
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// PathMode controls how source file paths are rendered in stack traces.
+type PathMode int
+
+const (
+	// PathFull renders the absolute path recorded at build time. This is the default.
+	PathFull PathMode = iota
+	// PathTrimmed strips user supplied prefixes, GOROOT, GOPATH and the module cache
+	// from the path, e.g. github.com/pkg/errors@v0.9.1/errors.go.
+	PathTrimmed
+	// PathModule renders the path relative to the module path without a version,
+	// e.g. github.com/pkg/errors/errors.go, using the dependencies listed in
+	// debug.ReadBuildInfo and the package path of the frame's function.
+	PathModule
+)
+
+// PathOptions configures how file paths are rewritten by Frame.Format,
+// Frame.MarshalText and, through it, JSON encoding of frames.
+type PathOptions struct {
+	Mode PathMode
+	// Prefixes are trimmed from file paths before any other rule is applied.
+	// They are only consulted in PathTrimmed and PathModule mode.
+	Prefixes []string
+}
+
+// pathOptions holds the PathOptions set by SetPathOptions.
+var pathOptions atomic.Value
+
+// SetPathOptions sets the path rewriting options. They apply to every stack
+// trace of the process, whichever Factory recorded it, as frames do not know
+// it. It is safe to call concurrently with formatting.
+func SetPathOptions(opts PathOptions) {
+	opts.Prefixes = append([]string(nil), opts.Prefixes...)
+	pathOptions.Store(opts)
+}
+
+// getPathOptions returns the path rewriting options set by SetPathOptions,
+// which are used to format every stack trace.
+func getPathOptions() PathOptions {
+	opts, _ := pathOptions.Load().(PathOptions)
+	return opts
+}
+
+// modCacheMarker is present in every file path that lives in a module cache,
+// regardless of the GOPATH used on the build machine.
+const modCacheMarker = "/pkg/mod/"
+
+var (
+	toolchainPrefixesOnce sync.Once
+	toolchainPrefixes     []string
+
+	buildModulesOnce sync.Once
+	buildModules     []string
+)
+
+// loadToolchainPrefixes returns the GOROOT, GOMODCACHE and GOPATH prefixes known
+// to this process, longest first so that nested directories win.
+func loadToolchainPrefixes() []string {
+	toolchainPrefixesOnce.Do(func() {
+		var prefixes []string
+		add := func(dir string) {
+			if dir != "" {
+				prefixes = append(prefixes, strings.TrimSuffix(filepath.ToSlash(dir), "/")+"/")
+			}
+		}
+		if root := runtime.GOROOT(); root != "" {
+			add(filepath.Join(root, "src"))
+		}
+		add(os.Getenv("GOMODCACHE"))
+		for _, dir := range filepath.SplitList(os.Getenv("GOPATH")) {
+			add(filepath.Join(dir, "pkg", "mod"))
+			add(filepath.Join(dir, "src"))
+		}
+		sortByLengthDesc(prefixes)
+		toolchainPrefixes = prefixes
+	})
+	return toolchainPrefixes
+}
+
+// loadBuildModules returns the module paths of the main module and its
+// dependencies, longest first.
+func loadBuildModules() []string {
+	buildModulesOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		var mods []string
+		if info.Main.Path != "" {
+			mods = append(mods, info.Main.Path)
+		}
+		for _, dep := range info.Deps {
+			mods = append(mods, dep.Path)
+		}
+		sortByLengthDesc(mods)
+		buildModules = mods
+	})
+	return buildModules
+}
+
+func sortByLengthDesc(s []string) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && len(s[j]) > len(s[j-1]); j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
+
+// rewritePath rewrites file, which belongs to the function named fn,
+// according to the configured PathOptions.
+func rewritePath(file, fn string) string {
+	opts := getPathOptions()
+	if opts.Mode == PathFull || file == "unknown" {
+		return file
+	}
+	file = trimPath(file, opts.Prefixes)
+	if opts.Mode == PathModule {
+		return modulePath(file, fn)
+	}
+	return file
+}
+
+// trimPath removes the first matching prefix from file, trying user supplied
+// prefixes before the toolchain ones and finally the module cache marker.
+func trimPath(file string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(file, prefix) {
+			return strings.TrimPrefix(strings.TrimPrefix(file, prefix), "/")
+		}
+	}
+	for _, prefix := range loadToolchainPrefixes() {
+		if strings.HasPrefix(file, prefix) {
+			return strings.TrimPrefix(file, prefix)
+		}
+	}
+	if i := strings.Index(file, modCacheMarker); i >= 0 {
+		return file[i+len(modCacheMarker):]
+	}
+	return file
+}
+
+// modulePath turns a trimmed file path into a module-path-relative name.
+// Files from the module cache have their @version element removed; any other
+// file is named after the package path of fn.
+func modulePath(file, fn string) string {
+	for _, mod := range loadBuildModules() {
+		if strings.HasPrefix(file, mod+"@") {
+			rest := file[len(mod)+1:]
+			if i := strings.IndexByte(rest, '/'); i >= 0 {
+				return mod + rest[i:]
+			}
+		}
+	}
+	if pkg := pkgpath(fn); pkg != "" {
+		return pkg + "/" + path.Base(file)
+	}
+	return file
+}
+
+// pkgpath returns the import path of the package declaring the function
+// named name, as reported by runtime.Func.Name().
+func pkgpath(name string) string {
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	i := strings.LastIndex(name, "/")
+	j := strings.IndexByte(name[i+1:], '.')
+	if j < 0 {
+		return ""
+	}
+	return name[:i+1+j]
+}
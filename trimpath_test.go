@@ -0,0 +1,87 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestTrimPath(t *testing.T) {
+	tests := []struct {
+		file     string
+		prefixes []string
+		want     string
+	}{
+		{"/home/ci/go/pkg/mod/github.com/pkg/errors@v0.9.1/errors.go", nil, "github.com/pkg/errors@v0.9.1/errors.go"},
+		{"/build/src/app/main.go", []string{"/build/src/"}, "app/main.go"},
+		{"/build/src/app/main.go", []string{"/build/src"}, "app/main.go"},
+		{"/build/src/app/main.go", []string{"/other"}, "/build/src/app/main.go"},
+	}
+	for _, tt := range tests {
+		if got := trimPath(tt.file, tt.prefixes); got != tt.want {
+			t.Errorf("trimPath(%q, %q): got %q, want %q", tt.file, tt.prefixes, got, tt.want)
+		}
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	tests := []struct {
+		file, fn string
+		want     string
+	}{
+		{"/home/ci/app/handler.go", "example.com/app/api.(*Server).Handle", "example.com/app/api/handler.go"},
+		{"runtime/asm_amd64.s", "runtime.goexit", "runtime/asm_amd64.s"},
+		{"github.com/pkg/errors@v0.9.1/errors.go", "github.com/pkg/errors.New", "github.com/pkg/errors/errors.go"},
+		{"/tmp/x.go", "", "/tmp/x.go"},
+	}
+	for _, tt := range tests {
+		if got := modulePath(tt.file, tt.fn); got != tt.want {
+			t.Errorf("modulePath(%q, %q): got %q, want %q", tt.file, tt.fn, got, tt.want)
+		}
+	}
+}
+
+func TestPkgpath(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"", ""},
+		{"runtime.main", "runtime"},
+		{"github.com/bynil/errors.funcname", "github.com/bynil/errors"},
+		{"main.(*R).Write", "main"},
+		{"example.com/a.F[go.shape.struct { example.com/b.X }]", "example.com/a"},
+	}
+	for _, tt := range tests {
+		if got := pkgpath(tt.name); got != tt.want {
+			t.Errorf("pkgpath(%q): got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFramePathOptions(t *testing.T) {
+	dir := regexp.MustCompile(`\t(.*)/stack_test.go`).FindStringSubmatch(fmt.Sprintf("%+s", initpc))
+	if dir == nil {
+		t.Fatal("could not determine source directory")
+	}
+	defer SetPathOptions(getPathOptions())
+
+	SetPathOptions(PathOptions{Mode: PathModule})
+	testFormatRegexp(t, 0, initpc, "%+v", "github.com/bynil/errors.init\n\tgithub.com/bynil/errors/stack_test.go:9")
+
+	got, err := initpc.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "github.com/bynil/errors.init github.com/bynil/errors/stack_test.go:9"
+	if string(got) != want {
+		t.Errorf("MarshalText: got %q, want %q", got, want)
+	}
+
+	SetPathOptions(PathOptions{Mode: PathTrimmed, Prefixes: []string{dir[1]}})
+	testFormatRegexp(t, 1, initpc, "%+v", "github.com/bynil/errors.init\n\tstack_test.go:9")
+
+	prefixes := []string{dir[1]}
+	SetPathOptions(PathOptions{Mode: PathTrimmed, Prefixes: prefixes})
+	prefixes[0] = "/nowhere"
+	testFormatRegexp(t, 2, initpc, "%+v", "github.com/bynil/errors.init\n\tstack_test.go:9")
+}